@@ -0,0 +1,186 @@
+package flago
+
+import "fmt"
+
+// An Argument is a positional argument of a flag set or a command, which is
+// bound to its Value at the end of Parse. A variadic argument takes all of
+// the arguments the others leave, calling Value.Set for each of them.
+type Argument struct {
+	Name     string
+	Usage    string
+	Value    Value
+	required bool
+	variadic bool
+	defValue string // the value restored by Reset
+}
+
+// SetRequired sets whether the argument must be given. Arguments are
+// required by default.
+func (a *Argument) SetRequired(required bool) *Argument {
+	a.required = required
+	return a
+}
+
+// SetVariadic sets whether the argument takes any number of arguments, at
+// least one if it is required. If more than one argument of a flag set or a
+// command is variadic, the first one takes the rest of the arguments.
+func (a *Argument) SetVariadic(variadic bool) *Argument {
+	a.variadic = variadic
+	return a
+}
+
+// IsRequired reports whether the argument must be given.
+func (a *Argument) IsRequired() bool {
+	return a.required
+}
+
+// IsVariadic reports whether the argument takes any number of arguments.
+func (a *Argument) IsVariadic() bool {
+	return a.variadic
+}
+
+// synopsis returns the argument as it is shown in the usage line,
+// such as "<src>...", "[name]".
+func (a *Argument) synopsis() string {
+	s := "<" + a.Name + ">"
+	if a.variadic {
+		s += "..."
+	}
+	if !a.required {
+		s = "[" + s + "]"
+	}
+	return s
+}
+
+// arguments returns the positional arguments defined on the flag set, or on
+// the command of a flag set returned by Command.Flags.
+func (f *FlagSet) arguments() *[]*Argument {
+	if f.command != nil {
+		return &f.command.arguments
+	}
+	return &f.positional
+}
+
+// ArgVar defines a positional argument with the specified value, name and
+// usage string. The arguments are bound in the order they are defined, and
+// like the flags, are restored to their default values before the next Parse.
+// To define an argument of a command, use the flag set of Command.Flags.
+func (f *FlagSet) ArgVar(value Value, name string, usage string) *Argument {
+	args := f.arguments()
+	for _, v := range *args {
+		if v.Name == name {
+			s := fmt.Sprintf("argument redefined: %s", name)
+			fmt.Fprintln(f.Output(), s)
+			panic(s)
+		}
+	}
+	a := &Argument{Name: name, Usage: usage, Value: value, required: true, defValue: value.String()}
+	*args = append(*args, a)
+	return a
+}
+
+// ArgVar defines a positional argument of the command line.
+func ArgVar(value Value, name string, usage string) *Argument {
+	return CommandLine.ArgVar(value, name, usage)
+}
+
+// StringArg defines a string positional argument with the specified name and
+// usage string. The return value is the address of a string variable that
+// stores the value of the argument.
+func (f *FlagSet) StringArg(name string, usage string) *string {
+	p := new(string)
+	f.ArgVar(newStringValue(p, ""), name, usage)
+	return p
+}
+
+// StringArg defines a string positional argument of the command line.
+func StringArg(name string, usage string) *string {
+	return CommandLine.StringArg(name, usage)
+}
+
+// scopeArguments returns the positional arguments of the deepest subcommand
+// selected, or of the top level if no subcommand is selected.
+func (f *FlagSet) scopeArguments() []*Argument {
+	if len(f.path) == 0 {
+		return f.positional
+	}
+	return f.path[len(f.path)-1].arguments
+}
+
+// synopsis returns the usage line, the command path followed by the
+// positional arguments.
+func (f *FlagSet) synopsis() string {
+	s := f.CommandPath()
+	for _, a := range f.scopeArguments() {
+		s += " " + a.synopsis()
+	}
+	return s
+}
+
+// bindArguments sets the positional arguments of the deepest subcommand
+// selected to the remaining arguments. Required arguments are filled first,
+// then optional ones in the order they are defined, and a variadic argument
+// takes the rest.
+func (f *FlagSet) bindArguments() error {
+	defs := f.scopeArguments()
+	if len(defs) == 0 {
+		return nil
+	}
+	args := f.Args()
+
+	// the number of arguments each of defs takes
+	takes := make([]int, len(defs))
+	variadic := -1
+	extra := len(args)
+	for i, a := range defs {
+		if a.variadic && variadic < 0 {
+			variadic = i
+		}
+		if a.required {
+			takes[i] = 1
+			extra--
+		}
+	}
+	for i, a := range defs {
+		if extra <= 0 {
+			break
+		}
+		if !a.required && i != variadic {
+			takes[i] = 1
+			extra--
+		}
+	}
+	if variadic >= 0 && extra > 0 {
+		takes[variadic] += extra
+	}
+
+	// check the number of the arguments before setting any of them
+	n := 0
+	for i, a := range defs {
+		if n+takes[i] > len(args) {
+			return &MissingPositionalError{Argument: a, Name: a.Name}
+		}
+		n += takes[i]
+	}
+	if n < len(args) {
+		return &UnexpectedArgumentError{Value: args[n], Index: n}
+	}
+
+	n = 0
+	for i, a := range defs {
+		for j := 0; j < takes[i]; j++ {
+			if err := a.Value.Set(args[n]); err != nil {
+				return &InvalidArgumentError{Argument: a, Name: a.Name, Value: args[n], Index: n, Err: err}
+			}
+			n++
+		}
+	}
+	return nil
+}
+
+// resetArguments restores the default values of the arguments.
+func resetArguments(args []*Argument) {
+	for _, a := range args {
+		resetValue(a.Value, a.defValue)
+	}
+}
@@ -0,0 +1,184 @@
+package flago
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// A Command is a sub-command with a handler. The flags of a command are
+// sub-flags, and child commands are nested sub-commands of it, so it is
+// parsed like any other sub-command. Execute calls the Run of the deepest
+// command selected.
+type Command struct {
+	// Run is called by Execute when the command is the deepest one selected.
+	// args are the non-flag arguments.
+	Run func(ctx context.Context, args []string) error
+
+	flag  *Flag
+	owner *FlagSet
+	flags *FlagSet // view defining the sub-flags, see Flags
+
+	// flags of the parent, which the long aliases are added to
+	parent map[string]*Flag
+}
+
+func (f *FlagSet) newCommand(name string, alias rune, usage string,
+	run func(context.Context, []string) error, subflags ...*Flag) *Command {
+	c := &Command{Run: run, owner: f.root()}
+	c.flag = f.Var(newBoolValue(new(bool), false), name, alias, usage, COMMAND, nil, subflags...)
+	c.flag.command = c
+	c.parent = f.flags
+	return c
+}
+
+// AddCommand defines a command with the specified name, alias, usage string,
+// handler and sub-flags. The handler may be nil for a command that only
+// groups child commands.
+func (f *FlagSet) AddCommand(name string, alias rune, usage string,
+	run func(context.Context, []string) error, subflags ...*Flag) *Command {
+	return f.newCommand(name, alias, usage, run, subflags...)
+}
+
+// AddCommand defines a command of the command line.
+func AddCommand(name string, alias rune, usage string,
+	run func(context.Context, []string) error, subflags ...*Flag) *Command {
+	return CommandLine.AddCommand(name, alias, usage, run, subflags...)
+}
+
+// AddCommand defines a child command of the command.
+func (c *Command) AddCommand(name string, alias rune, usage string,
+	run func(context.Context, []string) error, subflags ...*Flag) *Command {
+	return c.Flags().AddCommand(name, alias, usage, run, subflags...)
+}
+
+// SetAliases sets the long aliases of the command, such as "remove" and
+// "delete" for "rm", replacing the ones set before. It panics if an alias is
+// already defined next to the command.
+func (c *Command) SetAliases(aliases ...string) *Command {
+	for _, v := range c.flag.aliases {
+		delete(c.parent, v)
+	}
+	for _, v := range aliases {
+		if _, alreadythere := c.parent[v]; alreadythere {
+			s := fmt.Sprintf("flag redefined: %s", v)
+			fmt.Fprintln(c.owner.Output(), s)
+			panic(s)
+		}
+		c.parent[v] = c.flag
+	}
+	c.flag.aliases = append([]string(nil), aliases...)
+	return c
+}
+
+// Flags returns a flag set to define the sub-flags and child commands of the
+// command with any of the FlagSet methods, such as Int, DurationVar, Var or
+// AddCommand. It shares the output and the options of the flag set the
+// command belongs to, which is the one to parse the arguments with.
+func (c *Command) Flags() *FlagSet {
+	if c.flags == nil {
+		if c.flag.flags == nil {
+			c.flag.flags = make(map[string]*Flag)
+		}
+		c.flags = &FlagSet{
+			name:          c.flag.Name,
+			flags:         c.flag.flags,
+			errorHandling: c.owner.errorHandling,
+			parent:        c.owner,
+			command:       c.flag,
+		}
+	}
+	return c.flags
+}
+
+// Name returns the name of the command.
+func (c *Command) Name() string {
+	return c.flag.Name
+}
+
+// Flag returns the sub-command flag of the command.
+func (c *Command) Flag() *Flag {
+	return c.flag
+}
+
+// Lookup returns the Flag structure of the named sub-flag or child command
+// of the command, returning nil if none exists.
+func (c *Command) Lookup(name string) *Flag {
+	return c.flag.flags[name]
+}
+
+// SubFlag returns a sub-flag with the value of any type, to be passed as one
+// of the sub-flags of a sub-command or a command.
+func (f *FlagSet) SubFlag(value Value, name string, alias rune, usage string, callback Callback) *Flag {
+	return f.Var(value, name, alias, usage, NESTED, callback)
+}
+
+// SubFlag returns a sub-flag with the value of any type.
+func SubFlag(value Value, name string, alias rune, usage string, callback Callback) *Flag {
+	return CommandLine.SubFlag(value, name, alias, usage, callback)
+}
+
+// SubCommand returns a nested sub-command, to be passed as one of the
+// sub-flags of another sub-command or a command. No variable is bound to it;
+// the sub-commands selected are reported by CommandPath.
+func (f *FlagSet) SubCommand(name string, alias rune, usage string, subflags ...*Flag) *Flag {
+	return f.Var(newBoolValue(new(bool), false), name, alias, usage, COMMAND|NESTED, nil, subflags...)
+}
+
+// SubCommand returns a nested sub-command.
+func SubCommand(name string, alias rune, usage string, subflags ...*Flag) *Flag {
+	return CommandLine.SubCommand(name, alias, usage, subflags...)
+}
+
+// hasSubCommands reports whether any of the flags is a sub-command.
+func hasSubCommands(flags map[string]*Flag) bool {
+	for _, v := range flags {
+		if v.IsSubCommand() {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute parses the argument list, and calls the Run of the deepest command
+// selected, or the Run of the flag set if no command is selected, with the
+// non-flag arguments, returning its error.
+//
+// If there is no handler to call and the selected command has child commands,
+// an UnknownCommandError is returned for a remaining argument, or else the
+// usage message is printed and ErrHelp is returned. These errors, as well as
+// those of Parse, are handled as described by the ErrorHandling.
+func (f *FlagSet) Execute(ctx context.Context, arguments []string) error {
+	if err := f.Parse(arguments); err != nil {
+		return err
+	}
+
+	run := f.Run
+	if n := len(f.path); n > 0 {
+		run = nil
+		if c := f.path[n-1].command; c != nil {
+			run = c.Run
+		}
+	}
+	if run != nil {
+		return run(ctx, f.Args())
+	}
+
+	if !hasSubCommands(f.scopeFlags()) {
+		return nil
+	}
+	if f.NArg() > 0 {
+		name := f.Arg(0)
+		return f.handleError(f.fail(&UnknownCommandError{
+			Name: name, Token: name, Index: -1, Suggestions: f.suggestCommands(name),
+		}))
+	}
+	f.usage()
+	return f.handleError(ErrHelp)
+}
+
+// Execute parses the command-line arguments from os.Args[1:], and calls
+// the Run of the deepest command selected.
+func Execute() error {
+	return CommandLine.Execute(context.Background(), os.Args[1:])
+}
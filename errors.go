@@ -0,0 +1,185 @@
+package flago
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A MultiError is returned by Parse when more than one error occurred with
+// SetCollectErrors(true). It works with errors.Is and errors.As, which look
+// into each of the errors.
+type MultiError struct {
+	Errors []error
+}
+
+func (e *MultiError) Error() string {
+	s := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		s[i] = err.Error()
+	}
+	return strings.Join(s, "\n")
+}
+
+// Unwrap returns the errors.
+func (e *MultiError) Unwrap() []error {
+	return e.Errors
+}
+
+// optionName returns the option as it is written on the command line.
+func optionName(name string, alias rune) string {
+	if name == "" {
+		return "-" + string(alias)
+	}
+	return "--" + name
+}
+
+// An UnknownFlagError is returned when an option is not defined, or when an
+// abbreviated option matches more than one option.
+type UnknownFlagError struct {
+	Name       string   // the long name as given, or "" for a short option
+	Alias      rune     // the short name as given, or 0 for a long option
+	Token      string   // the argument the option was found in
+	Index      int      // the index of Token in the arguments, or -1
+	Candidates []string // the options an ambiguous abbreviation matches
+
+	// Suggestions are the options similar to the unknown one, such as
+	// "--verbose" for "--verbos", the closest first.
+	Suggestions []string
+}
+
+func (e *UnknownFlagError) Error() string {
+	if len(e.Candidates) > 0 {
+		return fmt.Sprintf("option `%s' is ambiguous; possibilities: --%s",
+			optionName(e.Name, e.Alias), strings.Join(e.Candidates, " --"))
+	}
+	return fmt.Sprintf("unrecognized option `%s'%s", optionName(e.Name, e.Alias), didYouMean(e.Suggestions))
+}
+
+// An OptionSyntaxError is returned when an argument beginning with "-" is
+// not an option, such as "---name", or "-bc=value" for the boolean options
+// "-b" and "-c".
+type OptionSyntaxError struct {
+	Token string // the argument
+	Index int    // the index of Token in the arguments, or -1
+}
+
+func (e *OptionSyntaxError) Error() string {
+	return fmt.Sprintf("invalid syntax as an option `%s'", e.Token)
+}
+
+// A MissingArgumentError is returned when an option requires an argument
+// but none was given.
+type MissingArgumentError struct {
+	Flag  *Flag
+	Name  string // the name of the flag
+	Alias rune   // the alias of the flag, or 0
+	Token string // the argument the option was found in
+	Index int    // the index of Token in the arguments, or -1
+}
+
+func (e *MissingArgumentError) Error() string {
+	return fmt.Sprintf("option `--%s' requires an argument", e.Name)
+}
+
+// An InvalidValueError is returned when the value of a flag fails to be set.
+// Err is the error returned by Value.Set, or a sentinel error that can be
+// checked with errors.Is: ErrParse or ErrRange for the built-in types,
+// ErrNotBool for a boolean flag whose Value does not hold a bool,
+// ErrChoice for enum flags, ErrPair or ErrDuplicateKey for map flags, and
+// ErrNegated for a value given to a negated option.
+type InvalidValueError struct {
+	Flag  *Flag
+	Name  string // the name of the flag
+	Alias rune   // the alias of the flag, or 0
+	Token string // the argument the option was found in
+	Index int    // the index of Token in the arguments, or -1
+	Value string // the value given to the flag
+	Err   error
+}
+
+func (e *InvalidValueError) Error() string {
+	return fmt.Sprintf("invalid value `%s' for option `--%s': %v", e.Value, e.Name, e.Err)
+}
+
+func (e *InvalidValueError) Unwrap() error {
+	return e.Err
+}
+
+// An UnknownCommandError is returned when a subcommand can not be resolved,
+// such as when an abbreviated subcommand matches more than one subcommand.
+type UnknownCommandError struct {
+	Name       string   // the command as given
+	Token      string   // the argument the command was found in
+	Index      int      // the index of Token in the arguments, or -1
+	Candidates []string // the commands an ambiguous abbreviation matches
+
+	// Suggestions are the commands similar to the unknown one, the closest first.
+	Suggestions []string
+}
+
+func (e *UnknownCommandError) Error() string {
+	if len(e.Candidates) > 0 {
+		return fmt.Sprintf("command `%s' is ambiguous; possibilities: %s",
+			e.Name, strings.Join(e.Candidates, " "))
+	}
+	return fmt.Sprintf("unknown command `%s'%s", e.Name, didYouMean(e.Suggestions))
+}
+
+// A CallbackError is returned when the callback of a flag fails.
+// Its message is that of Err.
+type CallbackError struct {
+	Flag  *Flag
+	Name  string // the name of the flag
+	Alias rune   // the alias of the flag, or 0
+	Token string // the argument the option was found in
+	Index int    // the index of Token in the arguments, or -1
+	Err   error
+}
+
+func (e *CallbackError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CallbackError) Unwrap() error {
+	return e.Err
+}
+
+// A MissingPositionalError is returned when a required positional argument
+// is not given.
+type MissingPositionalError struct {
+	Argument *Argument
+	Name     string // the name of the argument
+}
+
+func (e *MissingPositionalError) Error() string {
+	return fmt.Sprintf("missing argument <%s>", e.Name)
+}
+
+// An UnexpectedArgumentError is returned when more positional arguments are
+// given than are defined.
+type UnexpectedArgumentError struct {
+	Value string // the argument given
+	Index int    // the index of Value in Args
+}
+
+func (e *UnexpectedArgumentError) Error() string {
+	return fmt.Sprintf("unexpected argument `%s'", e.Value)
+}
+
+// An InvalidArgumentError is returned when the value of a positional
+// argument fails to be set. Err is the error returned by Value.Set.
+type InvalidArgumentError struct {
+	Argument *Argument
+	Name     string // the name of the argument
+	Value    string // the argument given
+	Index    int    // the index of Value in Args
+	Err      error
+}
+
+func (e *InvalidArgumentError) Error() string {
+	return fmt.Sprintf("invalid value `%s' for argument <%s>: %v", e.Value, e.Name, e.Err)
+}
+
+func (e *InvalidArgumentError) Unwrap() error {
+	return e.Err
+}
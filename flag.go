@@ -16,10 +16,13 @@ package flago
 		-f
 		-f value
 		-f=value
+		-fvalue
 
 		// mixed
 		-abc
 		-abc value
+		-abc=value
+		-abcvalue
 
 		// subcommand. defined init sub-command
 		$ command init -a -b -c
@@ -27,14 +30,22 @@ package flago
 		// there are other values in before a flag, does not end
 		$ command other -a other -b other -c other
 
+		// with SetInterspersed(false), the first non-flag argument ends the options
+		$ command other -a
+
+		// "--" terminates the options
+		$ command -a -- -b --c
+
 */
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"unicode/utf8"
 )
 
 const (
@@ -46,13 +57,30 @@ const (
 // but no such flag is defined.
 var ErrHelp = errors.New("flag: help requested")
 
-// errParse is returned by Set if a flag's value fails to parse, such as with an invalid integer for Int.
-// It then gets wrapped through failf to provide more information.
-var errParse = errors.New("parse error")
+// ErrParse is returned by Set if a flag's value fails to parse, such as with an invalid integer for Int.
+// Parse wraps it in an InvalidValueError to provide more information.
+var ErrParse = errors.New("parse error")
+
+// ErrRange is returned by Set if a flag's value is out of range.
+// Parse wraps it in an InvalidValueError to provide more information.
+var ErrRange = errors.New("value out of range")
+
+// ErrNotBool is returned if the value of a flag whose Value is a boolFlag is not a bool,
+// so it can not be inverted.
+var ErrNotBool = errors.New("type not a boolean")
+
+// ErrNegated is returned if a value is given to a negated option such as "--no-flag=true".
+var ErrNegated = errors.New("negated option doesn't allow an argument")
 
-// errRange is returned by Set if a flag's value is out of range.
-// It then gets wrapped through failf to provide more information.
-var errRange = errors.New("value out of range")
+// ErrPair is returned by Set if a value of a map flag is not a "key=value" pair.
+var ErrPair = errors.New("expected a key=value pair")
+
+// ErrDuplicateKey is returned by Set if a key is given twice to a map flag
+// that does not allow it.
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// ErrChoice is returned by Set if the value of an enum flag is not one of the choices.
+var ErrChoice = errors.New("must be one of")
 
 func numError(err error) error {
 	ne, ok := err.(*strconv.NumError)
@@ -60,10 +88,10 @@ func numError(err error) error {
 		return err
 	}
 	if ne.Err == strconv.ErrSyntax {
-		return errParse
+		return ErrParse
 	}
 	if ne.Err == strconv.ErrRange {
-		return errRange
+		return ErrRange
 	}
 	return err
 }
@@ -88,13 +116,41 @@ type FlagSet struct {
 	// to ExitOnError, which exits the program after calling Usage.
 	Usage func()
 
+	// Run is called by Execute when no command is selected.
+	// args are the non-flag arguments.
+	Run func(ctx context.Context, args []string) error
+
 	name          string
-	args          []string // argument other than flag
-	parsed        bool
-	index         int
 	flags         map[string]*Flag
 	errorHandling ErrorHandling
 	output        io.Writer
+	parent        *FlagSet // flag set of the command, for Command.Flags
+	command       *Flag    // the command, for Command.Flags
+	positional    []*Argument
+
+	posix              bool  // stop parsing at the first non-flag argument
+	interspersedSet    bool  // SetInterspersed was called, overriding POSIXLY_CORRECT
+	posixlyCorrect     bool  // honour POSIXLY_CORRECT
+	negatable          bool  // accept "--no-name" for boolean flags
+	abbrev             bool  // accept unique prefixes of long options
+	abbrevCommand      bool  // accept unique prefixes of subcommands
+	responseFiles      bool  // expand "@file" arguments
+	collectErrors      bool  // continue parsing after an error
+	noSuggestions      bool  // do not suggest similar names for unknown ones
+	suggestionDistance int   // maximum edit distance of suggestions
+	helpCommand        *Flag // "help" command, if enabled
+
+	// parsing state, which is cleared by Reset
+	parsed     bool
+	args       []string // argument other than flag
+	index      int
+	path       []*Flag // subcommands selected, outermost first
+	actual     map[*Flag]*flagUse
+	cuts       int    // number of arguments cut out of args
+	token      string // argument being parsed
+	tokenIndex int    // index of token in the arguments to Parse
+	dashAt     int    // index in args of the first argument after "--"
+	stopAtArg  bool   // posix mode in effect for the current parse
 }
 
 func NewFlagSet(name string, errorHandling ErrorHandling) *FlagSet {
@@ -118,14 +174,201 @@ func (f *FlagSet) Init(name string, errorHandling ErrorHandling) {
 	f.errorHandling = errorHandling
 }
 
+// A resetter is a Value that can restore its default value by itself.
+type resetter interface {
+	reset()
+}
+
+// resetValue restores the default value of a built-in Value type.
+func resetValue(value Value, def string) {
+	switch v := value.(type) {
+	case resetter:
+		v.reset()
+	case *boolValue, *countValue, *durationValue, *float64Value, *intValue,
+		*int64Value, *stringValue, *uintValue, *uint64Value:
+		// these parse the string they format
+		v.Set(def)
+	}
+}
+
+// SetInterspersed sets whether flags may be given after non-flag arguments.
+// When false, parsing stops at the first argument that is neither a flag nor
+// a subcommand, and it and the remaining arguments are left in Args().
+// The default is true, see also SetPosixlyCorrect.
+func (f *FlagSet) SetInterspersed(interspersed bool) {
+	f.posix = !interspersed
+	f.interspersedSet = true
+}
+
+// SetInterspersed sets whether command-line flags may be given after
+// non-flag arguments.
+func SetInterspersed(interspersed bool) {
+	CommandLine.SetInterspersed(interspersed)
+}
+
+// SetPosixlyCorrect sets whether the POSIXLY_CORRECT environment variable is
+// honoured. If it is, and the variable is set, parsing behaves as though
+// SetInterspersed(false) had been called, unless SetInterspersed has been
+// called explicitly. The default is false.
+func (f *FlagSet) SetPosixlyCorrect(enable bool) {
+	f.posixlyCorrect = enable
+}
+
+// SetPosixlyCorrect sets whether the command line honours the
+// POSIXLY_CORRECT environment variable.
+func SetPosixlyCorrect(enable bool) {
+	CommandLine.SetPosixlyCorrect(enable)
+}
+
+// SetNegatable sets whether "--no-name" is accepted for the boolean flags of
+// the flag set, including sub-flags. It applies to the flags already defined
+// and to those defined afterwards. See Flag.SetNegatable.
+func (f *FlagSet) SetNegatable(negatable bool) {
+	f.negatable = negatable
+	walkFlags(f.flags, func(flag *Flag) {
+		flag.negatable = negatable
+	})
+}
+
+// SetNegatable sets whether "--no-name" is accepted for the boolean
+// command-line flags.
+func SetNegatable(negatable bool) {
+	CommandLine.SetNegatable(negatable)
+}
+
+// SetAbbreviation sets whether a long option may be abbreviated to a prefix,
+// such as "--verb" for "--verbose", as long as the prefix is unique.
+func (f *FlagSet) SetAbbreviation(allow bool) {
+	f.abbrev = allow
+}
+
+// SetAbbreviation sets whether a long command-line option may be abbreviated
+// to a unique prefix.
+func SetAbbreviation(allow bool) {
+	CommandLine.SetAbbreviation(allow)
+}
+
+// SetCommandAbbreviation sets whether a subcommand may be abbreviated to
+// a prefix, such as "ini" for "init", as long as the prefix is unique.
+func (f *FlagSet) SetCommandAbbreviation(allow bool) {
+	f.abbrevCommand = allow
+}
+
+// SetCommandAbbreviation sets whether a subcommand of the command line may
+// be abbreviated to a unique prefix.
+func SetCommandAbbreviation(allow bool) {
+	CommandLine.SetCommandAbbreviation(allow)
+}
+
+// SetCollectErrors sets whether Parse continues after an unknown option or
+// an invalid value, to report all of the errors at once. Each error is still
+// written to Output() as it occurs. If more than one error occurred, Parse
+// returns them as a *MultiError. A help request stops parsing regardless.
+func (f *FlagSet) SetCollectErrors(collect bool) {
+	f.collectErrors = collect
+}
+
+// SetCollectErrors sets whether parsing the command line continues after
+// an error, to report all of the errors at once.
+func SetCollectErrors(collect bool) {
+	CommandLine.SetCollectErrors(collect)
+}
+
 // Name returns the name of the flag set.
 func (f *FlagSet) Name() string {
 	return f.name
 }
 
+// root returns the flag set that the flags are parsed with, which is the
+// parent of a flag set returned by Command.Flags.
+func (f *FlagSet) root() *FlagSet {
+	if f.parent != nil {
+		return f.parent
+	}
+	return f
+}
+
+// CommandPath returns the name of the flag set followed by the names of the
+// subcommands selected by Parse, such as "git remote add".
+func (f *FlagSet) CommandPath() string {
+	s := f.name
+	for _, v := range f.path {
+		s += " " + v.Name
+	}
+	return s
+}
+
+// CommandPath returns the name of the command line followed by the names of
+// the subcommands selected by Parse.
+func CommandPath() string { return CommandLine.CommandPath() }
+
+// scopeFlags returns the flags of the deepest subcommand selected,
+// or of the top level if no subcommand is selected.
+func (f *FlagSet) scopeFlags() map[string]*Flag {
+	if len(f.path) == 0 {
+		return f.flags
+	}
+	return f.path[len(f.path)-1].flags
+}
+
+// Reset clears the state of the last Parse, such as the arguments, the
+// subcommands selected and the flags recorded as set, as though Parse had not
+// been called. All of the flags, the sub-commands and the arguments are
+// restored to their default values, except those of user-defined Value
+// types, which are left as they are.
+func (f *FlagSet) Reset() {
+	walkFlags(f.flags, func(flag *Flag) {
+		resetValue(flag.Value, flag.DefValue)
+		resetArguments(flag.arguments)
+	})
+	resetArguments(f.positional)
+	f.actual = nil
+	f.clearParse()
+	f.parsed = false
+}
+
+// clearParse clears the state of the last Parse. The flags it set, the
+// subcommands it selected and the arguments it bound are restored to their
+// default values, so that parsing again starts over; the values set by Set
+// or by the program are left as they are.
+func (f *FlagSet) clearParse() {
+	for flag, u := range f.actual {
+		if u.parsed {
+			resetValue(flag.Value, flag.DefValue)
+			delete(f.actual, flag)
+		}
+	}
+	for _, flag := range f.path {
+		resetValue(flag.Value, flag.DefValue)
+		resetArguments(flag.arguments)
+	}
+	resetArguments(f.positional)
+
+	f.args = nil
+	f.index = 0
+	f.path = nil
+	f.cuts = 0
+	f.token, f.tokenIndex = "", 0
+	f.dashAt = -1
+	f.stopAtArg = false
+}
+
+// startParse prepares the flag set for a new Parse.
+func (f *FlagSet) startParse() {
+	f.clearParse()
+	f.parsed = true
+	f.stopAtArg = f.posix
+	if f.posixlyCorrect && !f.interspersedSet && os.Getenv("POSIXLY_CORRECT") != "" {
+		f.stopAtArg = true
+	}
+}
+
 // Output returns the destination for usage and error messages. os.Stderr is returned if
 // output was not set or was set to nil.
 func (f *FlagSet) Output() io.Writer {
+	if f.parent != nil {
+		return f.parent.Output()
+	}
 	if f.output == nil {
 		return os.Stderr
 	}
@@ -150,11 +393,108 @@ func (f *FlagSet) Parsed() bool { return f.parsed }
 // Parsed reports whether the command-line flags have been parsed.
 func Parsed() bool { return CommandLine.parsed }
 
+// flagUse records how a flag was set during Parse.
+type flagUse struct {
+	depth  int  // subcommand depth the flag was set in, 1 for the top level
+	count  int  // number of times the flag was set
+	parsed bool // set by Parse, rather than by Set
+}
+
+// markChanged records that the flag was set, by Parse if parsed is true.
+func (f *FlagSet) markChanged(flag *Flag, parsed bool) {
+	if f.actual == nil {
+		f.actual = make(map[*Flag]*flagUse)
+	}
+	if u, ok := f.actual[flag]; ok {
+		u.count++
+		u.parsed = u.parsed || parsed
+		return
+	}
+	f.actual[flag] = &flagUse{depth: len(f.path) + 1, count: 1, parsed: parsed}
+}
+
+// Set sets the value of the named flag of the deepest subcommand selected by
+// Parse, or of the top level, including the persistent flags of the parent
+// scopes. The name may be the long name or the alias.
+// As on the command line, an empty value inverts a boolean flag, or sets a
+// flag whose value is optional to its NoValue. The callback of the flag is
+// called, and the flag is recorded as set.
+func (f *FlagSet) Set(name, value string) error {
+	flag, ok := f.lookupFlag(name)
+	if !ok && utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		flag, ok = f.lookupFlag(aliasToKey(r))
+	}
+	if !ok {
+		return &UnknownFlagError{Name: name, Index: -1}
+	}
+
+	f.token, f.tokenIndex = value, -1
+	hasValue := value != "" || !isBoolFlag(flag) && !flag.optional
+	if err := f.apply(flag, value, hasValue); err != nil {
+		return err
+	}
+	f.markChanged(flag, false)
+	return nil
+}
+
+// Set sets the value of the named command-line flag.
+func Set(name, value string) error {
+	return CommandLine.Set(name, value)
+}
+
 // NFlag returns the number of flags that have been set.
-func (f *FlagSet) NFlag() int { return len(f.flags) }
+func (f *FlagSet) NFlag() int { return len(f.actual) }
 
 // NFlag returns the number of command-line flags that have been set.
-func NFlag() int { return len(CommandLine.flags) }
+func NFlag() int { return len(CommandLine.actual) }
+
+// resolveFlag returns the flag, not a subcommand, with the name or the
+// single-letter alias in the scopes of the subcommands selected, the deepest
+// one first, and then the top level.
+func (f *FlagSet) resolveFlag(name string) (*Flag, bool) {
+	keys := []string{name}
+	if utf8.RuneCountInString(name) == 1 {
+		r, _ := utf8.DecodeRuneInString(name)
+		keys = append(keys, aliasToKey(r))
+	}
+	for i := len(f.path) - 1; i >= -1; i-- {
+		for _, k := range keys {
+			if flag, ok := f.parentFlags(i)[k]; ok && !flag.IsSubCommand() {
+				return flag, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Changed reports whether the flag with the name has been set. The name is
+// resolved in the deepest subcommand selected first, and then in the parent
+// scopes, so a flag of the top level is not confused with a sub-flag of the
+// same name.
+func (f *FlagSet) Changed(name string) bool {
+	return f.Occurrences(name) > 0
+}
+
+// Changed reports whether the command-line flag with the name has been set.
+func Changed(name string) bool { return CommandLine.Changed(name) }
+
+// Occurrences returns the number of times the flag with the name has been
+// set. The name is resolved as in Changed.
+func (f *FlagSet) Occurrences(name string) int {
+	flag, ok := f.resolveFlag(name)
+	if !ok {
+		return 0
+	}
+	if u, ok := f.actual[flag]; ok {
+		return u.count
+	}
+	return 0
+}
+
+// Occurrences returns the number of times the command-line flag with the
+// name has been set.
+func Occurrences(name string) int { return CommandLine.Occurrences(name) }
 
 // Args returns the non-flag arguments.
 func (f *FlagSet) Args() []string { return f.args }
@@ -162,6 +502,31 @@ func (f *FlagSet) Args() []string { return f.args }
 // Args returns the non-flag command-line arguments.
 func Args() []string { return CommandLine.args }
 
+// ArgsLenAtDash returns the number of non-flag arguments that were given
+// before "--", or -1 if "--" was not given.
+func (f *FlagSet) ArgsLenAtDash() int {
+	if !f.parsed {
+		return -1
+	}
+	return f.dashAt
+}
+
+// ArgsLenAtDash returns the number of non-flag command-line arguments that
+// were given before "--", or -1 if "--" was not given.
+func ArgsLenAtDash() int { return CommandLine.ArgsLenAtDash() }
+
+// ArgsAfterDash returns the arguments given after "--", or nil if "--" was not given.
+func (f *FlagSet) ArgsAfterDash() []string {
+	if f.ArgsLenAtDash() < 0 {
+		return nil
+	}
+	return f.args[f.dashAt:]
+}
+
+// ArgsAfterDash returns the command-line arguments given after "--",
+// or nil if "--" was not given.
+func ArgsAfterDash() []string { return CommandLine.ArgsAfterDash() }
+
 // NArg is the number of arguments remaining after flags have been processed.
 func (f *FlagSet) NArg() int { return len(f.args) }
 
@@ -196,8 +561,8 @@ func (f *FlagSet) ErrorHandling() ErrorHandling {
 }
 
 var Usage = func() {
-	fmt.Fprintf(CommandLine.Output(), "\nUsage: %s\n\n", CommandLine.name)
-	PrintDefaults()
+	fmt.Fprintf(CommandLine.Output(), "\nUsage: %s\n\n", CommandLine.synopsis())
+	CommandLine.PrintCommandDefaults()
 }
 
 func init() {
@@ -212,10 +577,6 @@ func commandLineUsage() {
 	Usage()
 }
 
-func (f *FlagSet) addSubCommandName(name string) {
-	f.name += " " + name
-}
-
 type Value interface {
 	Set(string) error
 	Get() interface{}
@@ -239,14 +600,100 @@ type Flag struct {
 	Value        Value
 	callback     Callback
 	DefValue     string // default value (as text); for usage message
+	NoValue      string // value used when an optional value is not given
 	flags        map[string]*Flag
 	isSubCommand bool
+	posix        bool
+	negatable    bool
+	optional     bool
+	persistent   bool
+	command      *Command
+	arguments    []*Argument // positional arguments of the sub-command
+	aliases      []string    // long aliases of the sub-command
+	hidden       bool
+	deprecated   string
 }
 
 func (f *Flag) IsSubCommand() bool {
 	return f.isSubCommand
 }
 
+// SetInterspersed sets whether flags of the subcommand may be given after
+// non-flag arguments. When false, parsing stops at the first argument after
+// the subcommand that is neither a flag nor a nested subcommand.
+// It has no effect on flags that are not subcommands.
+func (f *Flag) SetInterspersed(interspersed bool) *Flag {
+	f.posix = !interspersed
+	return f
+}
+
+// SetNegatable sets whether "--no-name" is accepted for the flag.
+// A negatable flag is always set to false by "--no-name" and to true by
+// "--name", instead of being inverted.
+// It has no effect on flags that are not boolean.
+func (f *Flag) SetNegatable(negatable bool) *Flag {
+	f.negatable = negatable
+	return f
+}
+
+// SetOptionalValue makes the value of the flag optional, like "--color[=when]".
+// When the flag is given without "=", it is set to value instead of taking
+// the next argument. A value can still be attached to the short name, such
+// as "-cwhen". It has no effect on boolean flags.
+func (f *Flag) SetOptionalValue(value string) *Flag {
+	f.NoValue = value
+	f.optional = true
+	return f
+}
+
+// HasOptionalValue reports whether the value of the flag is optional.
+func (f *Flag) HasOptionalValue() bool {
+	return f.optional
+}
+
+// SetHidden sets whether the flag or sub-command is hidden. A hidden one is
+// still parsed, but it is not printed by PrintDefaults, suggested for an
+// unknown name, or matched by an abbreviation.
+func (f *Flag) SetHidden(hidden bool) *Flag {
+	f.hidden = hidden
+	return f
+}
+
+// IsHidden reports whether the flag or sub-command is hidden.
+func (f *Flag) IsHidden() bool {
+	return f.hidden
+}
+
+// SetDeprecated marks the flag or sub-command as deprecated. It is still
+// parsed, but a warning with the message, such as "use `remove' instead",
+// is printed to the output each time it is used. An empty message clears it.
+func (f *Flag) SetDeprecated(message string) *Flag {
+	f.deprecated = message
+	return f
+}
+
+// Deprecated returns the message of a deprecated flag or sub-command,
+// or "" if it is not deprecated.
+func (f *Flag) Deprecated() string {
+	return f.deprecated
+}
+
+// Aliases returns the long aliases of a sub-command, see Command.SetAliases.
+func (f *Flag) Aliases() []string {
+	return f.aliases
+}
+
+// walkFlags calls fn for each flag in flags and in the sub-flags of them.
+func walkFlags(flags map[string]*Flag, fn func(*Flag)) {
+	for k, v := range flags {
+		if k != v.Name {
+			continue
+		}
+		fn(v)
+		walkFlags(v.flags, fn)
+	}
+}
+
 func isValidAlias(alias rune) bool {
 	// alias is must be single alphabet letter
 	if alias > 0 {
@@ -278,6 +725,20 @@ func (f *Flag) subflagAlreadyThere(w io.Writer, name string) {
 	}
 }
 
+// addSubFlag adds a sub-flag or a nested sub-command to the flag.
+func (f *Flag) addSubFlag(w io.Writer, v *Flag) {
+	if f.flags == nil {
+		f.flags = make(map[string]*Flag)
+	}
+	f.subflagAlreadyThere(w, v.Name)
+	f.flags[v.Name] = v
+	if isValidAlias(v.Alias) {
+		a := aliasToKey(v.Alias)
+		f.subflagAlreadyThere(w, a)
+		f.flags[a] = v
+	}
+}
+
 func aliasToKey(alias rune) string {
 	return _ALIAS_PREFIX + string(alias)
 }
@@ -288,11 +749,13 @@ func aliasToKey(alias rune) string {
 
 // Var  defines a flag with the specified long short name, usage string, bit flags, callback, sub-flags.
 // fifth argument:
-//   0      : normal flag
-//   COMMAND: sub-command
-//   NESTED : nested sub-command or sub-flag.
-//            so, for nested subcommands,
-//            specify as follows COMMAND|NESTED
+//
+//	0      : normal flag
+//	COMMAND: sub-command
+//	NESTED : nested sub-command or sub-flag.
+//	         so, for nested subcommands,
+//	         specify as follows COMMAND|NESTED
+//	PERSISTENT: flag also valid in the descendant sub-commands
 func (f *FlagSet) Var(value Value, name string, alias rune, usage string, u uint, callback Callback, subflags ...*Flag) *Flag {
 	flag := &Flag{
 		Name:         name,
@@ -301,29 +764,24 @@ func (f *FlagSet) Var(value Value, name string, alias rune, usage string, u uint
 		DefValue:     value.String(),
 		callback:     callback,
 		isSubCommand: u&COMMAND == COMMAND,
+		persistent:   u&PERSISTENT == PERSISTENT && u&COMMAND != COMMAND,
+		negatable:    f.root().negatable,
 	}
 
-	if len(subflags) > 0 {
-		flag.flags = make(map[string]*Flag)
-
-		for _, v := range subflags {
-			flag.subflagAlreadyThere(f.Output(), v.Name)
-			flag.flags[v.Name] = v
-			if isValidAlias(v.Alias) {
-				a := aliasToKey(v.Alias)
-				flag.subflagAlreadyThere(f.Output(), a)
-				flag.flags[a] = v
-			}
-		}
+	for _, v := range subflags {
+		flag.addSubFlag(f.Output(), v)
 	}
 
-	f.flagAlreadyThere(name)
-
 	if isValidAlias(alias) {
 		flag.Alias = alias
 	}
 
+	// A sub-flag is checked against the flags of the sub-command it is added
+	// to, not against the top level, so it may shadow a flag of a parent
+	// scope. Only a persistent flag can not be redefined in the descendants,
+	// which checkPersistent detects.
 	if u&NESTED != NESTED {
+		f.flagAlreadyThere(name)
 		if f.flags == nil {
 			f.flags = make(map[string]*Flag)
 		}
@@ -334,6 +792,9 @@ func (f *FlagSet) Var(value Value, name string, alias rune, usage string, u uint
 			f.flagAlreadyThere(a)
 			f.flags[a] = flag
 		}
+
+		root := f.root()
+		root.checkPersistent(root.flags, nil)
 	}
 
 	return flag
@@ -2,6 +2,8 @@ package flago
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -458,6 +460,12 @@ func TestPrintDefaults(t *testing.T) {
 	buf.Reset()
 
 	fs.PrintDefaults()
+	if got = buf.String(); got != defaultOutput {
+		t.Errorf("PrintDefaults after Parse\n\n- want:\n\n'%s'\n\n- got:\n\n'%s'\n", defaultOutput, got)
+	}
+	buf.Reset()
+
+	fs.PrintCommandDefaults()
 	got = buf.String()
 	if got != subCmdAOutput {
 		index := -1
@@ -471,10 +479,10 @@ func TestPrintDefaults(t *testing.T) {
 			index, len(got), subCmdAOutput, got)
 	}
 
-	fs.Parse([]string{"subcmdB"})
+	fs.Parse([]string{"subcmdA", "subcmdB"})
 	buf.Reset()
 
-	fs.PrintDefaults()
+	fs.PrintCommandDefaults()
 	got = buf.String()
 	if got != subCmdBOutput {
 		index := -1
@@ -610,10 +618,13 @@ func TestAddSubCommandName(t *testing.T) {
 	)
 
 	CommandLine.Parse(args)
-	actual := CommandLine.Name()
+	actual := CommandLine.CommandPath()
 	if actual != expect {
 		t.Errorf("\ngot : %s, want: %s\n", actual, expect)
 	}
+	if CommandLine.Name() != Name {
+		t.Errorf("\ngot : %s, want: %s\n", CommandLine.Name(), Name)
+	}
 }
 
 func TestSubCommand(t *testing.T) {
@@ -688,10 +699,15 @@ func TestShortFlag(t *testing.T) {
 			shouldBeAnError: false,
 		},
 		{
-			name:            "Equals can not be used with consecutive short flags",
-			args:            []string{"-bcd=value"},
+			name:            "Equals can not be used with consecutive bool flags",
+			args:            []string{"-bc=value"},
 			shouldBeAnError: true,
 		},
+		{
+			name:            "Specify the value by a equal after consecutive short flags",
+			args:            []string{"-bcd=value"},
+			shouldBeAnError: false,
+		},
 		{
 			name:            "Specify the value by a equal",
 			args:            []string{"-d=value"},
@@ -731,3 +747,1469 @@ func TestShortFlag(t *testing.T) {
 		}
 	}
 }
+
+func TestDoubleDash(t *testing.T) {
+	data := []struct {
+		args      []string
+		expect    []string
+		lenAtDash int
+		force     bool
+	}{
+		{
+			args:      []string{"a", "--force", "b"},
+			expect:    []string{"a", "b"},
+			lenAtDash: -1,
+			force:     true,
+		},
+		{
+			args:      []string{"a", "--", "-rf", "--force", "sub"},
+			expect:    []string{"a", "-rf", "--force", "sub"},
+			lenAtDash: 1,
+			force:     false,
+		},
+		{
+			args:      []string{"--force", "--", "--"},
+			expect:    []string{"--"},
+			lenAtDash: 0,
+			force:     true,
+		},
+	}
+
+	for i, v := range data {
+		fs := NewFlagSet("double dash test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		force := fs.Bool("force", 'f', false, "", nil)
+		fs.BoolSubCommand("sub", -1, "")
+
+		if err := fs.Parse(v.args); err != nil {
+			t.Errorf(" %d: error: %s\n", i, err)
+			continue
+		}
+		if *force != v.force {
+			t.Errorf(" %d: force - got: %t, want: %t\n", i, *force, v.force)
+		}
+		if fmt.Sprint(fs.Args()) != fmt.Sprint(v.expect) {
+			t.Errorf(" %d: args - got: %q, want: %q\n", i, fs.Args(), v.expect)
+		}
+		if fs.ArgsLenAtDash() != v.lenAtDash {
+			t.Errorf(" %d: ArgsLenAtDash - got: %d, want: %d\n", i, fs.ArgsLenAtDash(), v.lenAtDash)
+		}
+		if v.lenAtDash >= 0 {
+			after := fs.ArgsAfterDash()
+			if fmt.Sprint(after) != fmt.Sprint(v.expect[v.lenAtDash:]) {
+				t.Errorf(" %d: ArgsAfterDash - got: %q, want: %q\n", i, after, v.expect[v.lenAtDash:])
+			}
+		} else if fs.ArgsAfterDash() != nil {
+			t.Errorf(" %d: ArgsAfterDash - got: %q, want: nil\n", i, fs.ArgsAfterDash())
+		}
+	}
+}
+
+func TestInterspersed(t *testing.T) {
+	data := []struct {
+		name   string
+		posix  bool
+		sub    bool
+		args   []string
+		expect []string
+		verb   bool
+		all    bool
+	}{
+		{
+			name:   "interspersed",
+			args:   []string{"cmd", "--all", "exec", "cmd", "-v"},
+			expect: []string{"cmd", "cmd"},
+			verb:   true,
+			all:    true,
+		},
+		{
+			name:   "posix",
+			posix:  true,
+			args:   []string{"--all", "cmd", "-v"},
+			expect: []string{"cmd", "-v"},
+			all:    true,
+		},
+		{
+			name:   "posix subcommand is still recognized",
+			posix:  true,
+			args:   []string{"exec", "-v", "cmd", "-v"},
+			expect: []string{"cmd", "-v"},
+			verb:   true,
+		},
+		{
+			name:   "posix only in the subcommand",
+			sub:    true,
+			args:   []string{"--all", "exec", "cmd", "-v"},
+			expect: []string{"cmd", "-v"},
+			all:    true,
+		},
+		{
+			name:   "posix only in the subcommand, not at the top level",
+			sub:    true,
+			args:   []string{"other", "--all", "exec", "cmd", "-v"},
+			expect: []string{"other", "cmd", "-v"},
+			all:    true,
+		},
+	}
+
+	for _, v := range data {
+		var verb, all bool
+		fs := NewFlagSet("interspersed test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.SetInterspersed(!v.posix)
+		fs.BoolVar(&all, "all", 'a', false, "", nil)
+		fs.BoolSubCommand("exec", -1, "",
+			fs.BoolVarSubFlag(&verb, "verbose", 'v', false, "", nil),
+		)
+		fs.Lookup("exec").SetInterspersed(!v.sub)
+
+		if err := fs.Parse(v.args); err != nil {
+			t.Errorf("%s: error: %s\n", v.name, err)
+			continue
+		}
+		if fmt.Sprint(fs.Args()) != fmt.Sprint(v.expect) {
+			t.Errorf("%s: args - got: %q, want: %q\n", v.name, fs.Args(), v.expect)
+		}
+		if verb != v.verb || all != v.all {
+			t.Errorf("%s: got: verbose=%t all=%t, want: verbose=%t all=%t\n", v.name, verb, all, v.verb, v.all)
+		}
+	}
+}
+
+func TestPosixlyCorrect(t *testing.T) {
+	old, ok := os.LookupEnv("POSIXLY_CORRECT")
+	os.Setenv("POSIXLY_CORRECT", "1")
+	defer func() {
+		if ok {
+			os.Setenv("POSIXLY_CORRECT", old)
+		} else {
+			os.Unsetenv("POSIXLY_CORRECT")
+		}
+	}()
+
+	data := []struct {
+		name         string
+		enable       bool
+		interspersed bool // SetInterspersed(true) is called
+		expect       []string
+	}{
+		{name: "not enabled", expect: []string{"cmd"}},
+		{name: "enabled", enable: true, expect: []string{"cmd", "-v"}},
+		{name: "explicitly interspersed", enable: true, interspersed: true, expect: []string{"cmd"}},
+	}
+
+	for _, v := range data {
+		fs := NewFlagSet("posixly correct test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.SetPosixlyCorrect(v.enable)
+		if v.interspersed {
+			fs.SetInterspersed(true)
+		}
+		fs.Bool("verbose", 'v', false, "", nil)
+
+		if err := fs.Parse([]string{"cmd", "-v"}); err != nil {
+			t.Errorf("%s: error: %s\n", v.name, err)
+			continue
+		}
+		if fmt.Sprint(fs.Args()) != fmt.Sprint(v.expect) {
+			t.Errorf("%s: args - got: %q, want: %q\n", v.name, fs.Args(), v.expect)
+		}
+	}
+}
+
+func TestAttachedShortValue(t *testing.T) {
+	data := []struct {
+		args   []string
+		verb   bool
+		output string
+		count  int
+		rest   []string
+	}{
+		{args: []string{"-ofile.txt"}, output: "file.txt"},
+		{args: []string{"-vo", "out.txt"}, verb: true, output: "out.txt"},
+		{args: []string{"-voout.txt", "arg"}, verb: true, output: "out.txt", rest: []string{"arg"}},
+		{args: []string{"-n5", "-o=x"}, count: 5, output: "x"},
+		{args: []string{"-vn", "7"}, verb: true, count: 7},
+		{args: []string{"-oname=value"}, output: "name=value"},
+		{args: []string{"-vo-"}, verb: true, output: "-"},
+		{args: []string{"-vo=x"}, verb: true, output: "x"},
+		{args: []string{"-vo=x=y"}, verb: true, output: "x=y"},
+	}
+
+	for i, v := range data {
+		fs := NewFlagSet("attached short value test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		verb := fs.Bool("verbose", 'v', false, "", nil)
+		output := fs.String("output", 'o', "", "", nil)
+		count := fs.Int("count", 'n', 0, "", nil)
+
+		if err := fs.Parse(v.args); err != nil {
+			t.Errorf(" %d: error: %s\n", i, err)
+			continue
+		}
+		if *verb != v.verb || *output != v.output || *count != v.count {
+			t.Errorf(" %d: got: (%t, %q, %d), want: (%t, %q, %d)\n",
+				i, *verb, *output, *count, v.verb, v.output, v.count)
+		}
+		if fmt.Sprint(fs.Args()) != fmt.Sprint(v.rest) {
+			t.Errorf(" %d: args - got: %q, want: %q\n", i, fs.Args(), v.rest)
+		}
+	}
+
+	// an invalid cluster is rejected before any of its options is set
+	for i, args := range [][]string{{"-vx"}, {"-vv="}, {"-vn"}} {
+		fs := NewFlagSet("attached short value test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		verb := fs.Bool("verbose", 'v', false, "", nil)
+		fs.Int("count", 'n', 0, "", nil)
+		if err := fs.Parse(args); err == nil || *verb {
+			t.Errorf(" %d: invalid cluster - got: (%v, %t)", i, err, *verb)
+		}
+	}
+}
+
+func TestNegatable(t *testing.T) {
+	data := []struct {
+		args            []string
+		color, verbose  bool
+		shouldBeAnError bool
+	}{
+		{args: []string{"--color", "--color"}, color: true},
+		{args: []string{"--no-color"}, color: false},
+		{args: []string{"-c", "--no-color", "-c"}, color: true},
+		{args: []string{"--no-color=true"}, shouldBeAnError: true},
+		{args: []string{"--no-verbose"}, shouldBeAnError: true},
+		{args: []string{"--verbose", "--verbose"}, color: true, verbose: false},
+		{args: []string{"--no-name"}, color: true, shouldBeAnError: true},
+	}
+
+	for i, v := range data {
+		fs := NewFlagSet("negatable test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		color := fs.Bool("color", 'c', true, "", nil)
+		fs.String("name", -1, "", "", nil)
+		fs.SetNegatable(true)
+		verbose := fs.Bool("verbose", 'v', false, "", nil)
+		fs.Lookup("verbose").SetNegatable(false)
+
+		err := fs.Parse(v.args)
+		if v.shouldBeAnError {
+			if err == nil {
+				t.Errorf(" %d: should be an error\n", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf(" %d: error: %s\n", i, err)
+			continue
+		}
+		if *color != v.color || *verbose != v.verbose {
+			t.Errorf(" %d: got: (%t, %t), want: (%t, %t)\n", i, *color, *verbose, v.color, v.verbose)
+		}
+	}
+
+	fs := NewFlagSet("negatable test", ContinueOnError)
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.Bool("color", 'c', false, "colorize", nil)
+	fs.Lookup("color").SetNegatable(true)
+	fs.PrintDefaults()
+	expect := "Options:\n  -c, --[no-]color      colorize\n\n"
+	if got := buf.String(); got != expect {
+		t.Errorf("PrintDefaults - got: %q, want: %q", got, expect)
+	}
+}
+
+func TestAbbreviation(t *testing.T) {
+	data := []struct {
+		args            []string
+		abbrev          bool
+		expect          string
+		shouldBeAnError bool
+	}{
+		{args: []string{"--verb"}, abbrev: true, expect: "verbose"},
+		{args: []string{"--verbose"}, abbrev: true, expect: "verbose"},
+		{args: []string{"--vers"}, abbrev: true, expect: "version"},
+		{args: []string{"--ver"}, abbrev: true, shouldBeAnError: true},
+		{args: []string{"--verb"}, abbrev: false, shouldBeAnError: true},
+		{args: []string{"--ini"}, abbrev: true, shouldBeAnError: true},
+		{args: []string{"ini", "--lo"}, abbrev: true, expect: "init local"},
+		{args: []string{"ini"}, abbrev: false, expect: ""},
+		{args: []string{"i"}, abbrev: true, shouldBeAnError: true},
+	}
+
+	for i, v := range data {
+		var verbose, version, initialize, install, local bool
+		fs := NewFlagSet("abbreviation test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.SetAbbreviation(v.abbrev)
+		fs.SetCommandAbbreviation(v.abbrev)
+		fs.BoolVar(&verbose, "verbose", -1, false, "", nil)
+		fs.BoolVar(&version, "version", -1, false, "", nil)
+		fs.BoolVarSubCommand(&initialize, "init", -1, "",
+			fs.BoolVarSubFlag(&local, "local", -1, false, "", nil),
+		)
+		fs.BoolVarSubCommand(&install, "install", -1, "")
+
+		err := fs.Parse(v.args)
+		if v.shouldBeAnError {
+			if err == nil {
+				t.Errorf(" %d: should be an error\n", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf(" %d: error: %s\n", i, err)
+			continue
+		}
+
+		var got []string
+		for _, b := range []struct {
+			name string
+			set  bool
+		}{
+			{"verbose", verbose}, {"version", version}, {"init", initialize},
+			{"install", install}, {"local", local},
+		} {
+			if b.set {
+				got = append(got, b.name)
+			}
+		}
+		if strings.Join(got, " ") != v.expect {
+			t.Errorf(" %d: got: %q, want: %q\n", i, strings.Join(got, " "), v.expect)
+		}
+	}
+}
+
+func TestOptionalValue(t *testing.T) {
+	data := []struct {
+		args   []string
+		color  string
+		rest   []string
+		expect string
+	}{
+		{args: []string{"--color"}, color: "always"},
+		{args: []string{"--color", "never"}, color: "always", rest: []string{"never"}},
+		{args: []string{"--color=never"}, color: "never"},
+		{args: []string{"-c", "never"}, color: "always", rest: []string{"never"}},
+		{args: []string{"-c=never"}, color: "never"},
+		{args: []string{"-cnever"}, color: "never"},
+		{args: []string{"-vc", "x"}, color: "always", rest: []string{"x"}},
+		{args: []string{}, color: "auto"},
+	}
+
+	for i, v := range data {
+		fs := NewFlagSet("optional value test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.Bool("verbose", 'v', false, "", nil)
+		color := fs.String("color", 'c', "auto", "", nil)
+		fs.Lookup("color").SetOptionalValue("always")
+
+		if err := fs.Parse(v.args); err != nil {
+			t.Errorf(" %d: error: %s\n", i, err)
+			continue
+		}
+		if *color != v.color {
+			t.Errorf(" %d: got: %q, want: %q\n", i, *color, v.color)
+		}
+		if fmt.Sprint(fs.Args()) != fmt.Sprint(v.rest) {
+			t.Errorf(" %d: args - got: %q, want: %q\n", i, fs.Args(), v.rest)
+		}
+	}
+
+	fs := NewFlagSet("optional value test", ContinueOnError)
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.String("color", 'c', "auto", "colorize the output `when`", nil)
+	fs.Lookup("color").SetOptionalValue("always")
+	if name, _ := UnquoteUsage(fs.Lookup("color")); name != "[=when]" {
+		t.Errorf("UnquoteUsage - got: %q, want: %q", name, "[=when]")
+	}
+	fs.PrintDefaults()
+	expect := "Options:\n  -c, --color[=when]    colorize the output when\n\n"
+	if got := buf.String(); got != expect {
+		t.Errorf("PrintDefaults - got: %q, want: %q", got, expect)
+	}
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flago")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"args":    "# comment\n--name 'hello world'\n  \"a \\\"b\\\"\" @" + dir + "/nested\n",
+		"nested":  "-v c\\ d\n",
+		"cycle":   "@" + dir + "/cycle\n",
+		"invalid": "-v\n'unterminated\n",
+	}
+	for k, v := range files {
+		if err := ioutil.WriteFile(dir+"/"+k, []byte(v), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := []struct {
+		args   []string
+		expect []string
+		name   string
+		err    string
+	}{
+		{
+			args:   []string{"x", "@" + dir + "/args", "y"},
+			expect: []string{"x", "a \"b\"", "c d", "y"},
+			name:   "hello world",
+		},
+		{
+			args:   []string{"@@literal", "@", "--", "@" + dir + "/args"},
+			expect: []string{"@literal", "@", "@" + dir + "/args"},
+		},
+		{
+			args: []string{"@" + dir + "/cycle"},
+			err:  "includes itself",
+		},
+		{
+			args: []string{"@" + dir + "/invalid"},
+			err:  dir + "/invalid:2:",
+		},
+		{
+			args: []string{"@" + dir + "/none"},
+			err:  "none",
+		},
+	}
+
+	for i, v := range data {
+		fs := NewFlagSet("response files test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.SetResponseFiles(true)
+		name := fs.String("name", 'n', "", "", nil)
+		fs.Bool("verbose", 'v', false, "", nil)
+
+		err := fs.Parse(v.args)
+		if v.err != "" {
+			if err == nil || !strings.Contains(err.Error(), v.err) {
+				t.Errorf(" %d: error - got: %v, want: %q\n", i, err, v.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf(" %d: error: %s\n", i, err)
+			continue
+		}
+		if *name != v.name {
+			t.Errorf(" %d: name - got: %q, want: %q\n", i, *name, v.name)
+		}
+		if fmt.Sprintf("%q", fs.Args()) != fmt.Sprintf("%q", v.expect) {
+			t.Errorf(" %d: args - got: %q, want: %q\n", i, fs.Args(), v.expect)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	env := map[string]string{"HOME": "/home/gopher", "EMPTY": "", "SP": "a b"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	data := []struct {
+		in     string
+		lookup bool
+		expect []string
+		err    string
+	}{
+		{in: "  a  b\tc\n", expect: []string{"a", "b", "c"}},
+		{in: `'a b' "c d" e\ f`, expect: []string{"a b", "c d", "e f"}},
+		{in: `'' "" x`, expect: []string{"", "", "x"}},
+		{in: `"a\"b\\c\d" 'a\b'`, expect: []string{`a"b\c\d`, `a\b`}},
+		{in: "a\\\nb # comment\nc#d", expect: []string{"ab", "c#d"}},
+		{in: `$HOME/x "${HOME}" '$HOME' \$HOME`, lookup: true, expect: []string{"/home/gopher/x", "/home/gopher", "$HOME", "$HOME"}},
+		{in: `$HOME`, expect: []string{"$HOME"}},
+		{in: `$EMPTY a "$EMPTY" $UNSET $SP $ 1$`, lookup: true, expect: []string{"a", "", "a b", "$", "1$"}},
+		{in: `a 'bc`, err: "1:3: unterminated single-quoted string"},
+		{in: "a\n  \"bc", err: "2:3: unterminated double-quoted string"},
+		{in: `ab\`, err: "1:3: backslash at end of input"},
+		{in: `x ${HOME`, lookup: true, err: "1:3: unterminated ${"},
+		{in: `x "${}"`, lookup: true, err: "1:4: bad substitution"},
+	}
+
+	for i, v := range data {
+		var fn func(string) (string, bool)
+		if v.lookup {
+			fn = lookup
+		}
+		got, err := SplitArgs(v.in, fn)
+		if v.err != "" {
+			if err == nil || err.Error() != v.err {
+				t.Errorf(" %d: error - got: %v, want: %q\n", i, err, v.err)
+			} else if _, ok := err.(*SyntaxError); !ok {
+				t.Errorf(" %d: error - got: %T, want: *SyntaxError\n", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf(" %d: error: %s\n", i, err)
+			continue
+		}
+		if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", v.expect) {
+			t.Errorf(" %d: got: %q, want: %q\n", i, got, v.expect)
+		}
+	}
+
+	fs := NewFlagSet("parse string test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	name := fs.String("name", 'n', "", "", nil)
+	if err := fs.ParseString(`--name "$HOME" -- 'x y'`, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if *name != "/home/gopher" || fs.NArg() != 1 || fs.Arg(0) != "x y" {
+		t.Errorf("ParseString - got: (%q, %q)", *name, fs.Args())
+	}
+	if err := fs.ParseString(`--name "x`, nil); err == nil {
+		t.Error("ParseString - expected error; got none")
+	}
+	if fs.NArg() != 0 || fs.ArgsLenAtDash() != -1 {
+		t.Errorf("ParseString - got: (%q, %d)", fs.Args(), fs.ArgsLenAtDash())
+	}
+}
+
+func TestCollectErrors(t *testing.T) {
+	fs := NewFlagSet("collect errors test", ContinueOnError)
+	var buf bytes.Buffer
+	fs.SetOutput(&buf)
+	fs.SetCollectErrors(true)
+	n := fs.Int("num", 'n', 0, "", nil)
+	s := fs.String("str", 's', "", "", nil)
+
+	err := fs.Parse([]string{"--unknown", "--num=x", "arg", "-s", "ok", "-x", "--str"})
+	me, ok := err.(*MultiError)
+	if !ok {
+		t.Fatalf("expected *MultiError; got %T: %v", err, err)
+	}
+	if len(me.Errors) != 4 {
+		t.Errorf("expected 4 errors; got %d: %v", len(me.Errors), me.Errors)
+	}
+	if !errors.Is(err, ErrParse) {
+		t.Error("errors.Is(err, ErrParse) = false")
+	}
+	if *n != 0 || *s != "ok" || fs.NArg() != 1 || fs.Arg(0) != "arg" {
+		t.Errorf("got: (%d, %q, %q)", *n, *s, fs.Args())
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 4 {
+		t.Errorf("expected 4 lines of output; got %q", buf.String())
+	}
+
+	if err := fs.Parse([]string{"--unknown"}); err == nil || err.Error() != "unrecognized option `--unknown'" {
+		t.Errorf("expected a single error; got %v", err)
+	}
+	if err := fs.Parse([]string{"--unknown", "--help", "--num=x"}); err != ErrHelp {
+		t.Errorf("expected ErrHelp; got %v", err)
+	}
+}
+
+func TestTypedErrors(t *testing.T) {
+	fs := NewFlagSet("typed errors test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.SetNegatable(true)
+	fs.SetAbbreviation(true)
+	fs.SetCommandAbbreviation(true)
+	fs.Bool("verbose", 'v', false, "", nil)
+	fs.Bool("version", 'V', false, "", nil)
+	fs.Int("num", 'n', 0, "", nil)
+	fs.String("digits", 'd', "", "", func(v Value) error {
+		return fmt.Errorf("`%s' is not a number", v)
+	})
+	fs.BoolSubCommand("init", -1, "")
+	fs.BoolSubCommand("install", -1, "")
+	fs.Var(&boolFlagVar{}, "odd", -1, "", 0, nil)
+
+	var unknownFlag *UnknownFlagError
+	var syntax *OptionSyntaxError
+	var missing *MissingArgumentError
+	var invalid *InvalidValueError
+	var unknownCommand *UnknownCommandError
+	var callback *CallbackError
+
+	err := fs.Parse([]string{"arg", "--unknown"})
+	if !errors.As(err, &unknownFlag) || unknownFlag.Name != "unknown" || unknownFlag.Token != "--unknown" || unknownFlag.Index != 1 {
+		t.Errorf("UnknownFlagError - got: %#v", err)
+	}
+	err = fs.Parse([]string{"-vx"})
+	if !errors.As(err, &unknownFlag) || unknownFlag.Alias != 'x' || unknownFlag.Token != "-vx" || unknownFlag.Index != 0 {
+		t.Errorf("UnknownFlagError - got: %#v", err)
+	}
+	err = fs.Parse([]string{"--ver"})
+	if !errors.As(err, &unknownFlag) || fmt.Sprint(unknownFlag.Candidates) != "[verbose version]" {
+		t.Errorf("UnknownFlagError - got: %#v", err)
+	}
+	for i, v := range []string{"---verbose", "-=", "-vV=x"} {
+		err = fs.Parse([]string{"arg", v})
+		if !errors.As(err, &syntax) || syntax.Token != v || syntax.Index != 1 {
+			t.Errorf(" %d: OptionSyntaxError - got: %#v", i, err)
+		}
+	}
+	err = fs.Parse([]string{"a", "b", "-n"})
+	if !errors.As(err, &missing) || missing.Name != "num" || missing.Alias != 'n' || missing.Index != 2 {
+		t.Errorf("MissingArgumentError - got: %#v", err)
+	}
+	err = fs.Parse([]string{"--num", "x"})
+	if !errors.As(err, &invalid) || invalid.Name != "num" || invalid.Value != "x" || invalid.Flag != fs.Lookup("num") || !errors.Is(err, ErrParse) {
+		t.Errorf("InvalidValueError - got: %#v", err)
+	}
+	err = fs.Parse([]string{"--no-verbose=x"})
+	if !errors.As(err, &invalid) || invalid.Name != "verbose" || !errors.Is(err, ErrNegated) {
+		t.Errorf("InvalidValueError - got: %#v", err)
+	}
+	err = fs.Parse([]string{"--odd"})
+	if !errors.As(err, &invalid) || invalid.Name != "odd" || !errors.Is(err, ErrNotBool) {
+		t.Errorf("InvalidValueError - got: %#v", err)
+	}
+	err = fs.Parse([]string{"x", "in"})
+	if !errors.As(err, &unknownCommand) || unknownCommand.Name != "in" || unknownCommand.Index != 1 || len(unknownCommand.Candidates) != 2 {
+		t.Errorf("UnknownCommandError - got: %#v", err)
+	}
+	err = fs.Parse([]string{"-d12a"})
+	if !errors.As(err, &callback) || callback.Name != "digits" || callback.Token != "-d12a" || err.Error() != "`12a' is not a number" {
+		t.Errorf("CallbackError - got: %#v", err)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	data := []struct {
+		args        []string
+		disable     bool
+		distance    int
+		suggestions []string
+	}{
+		{args: []string{"--verbos"}, suggestions: []string{"--verbose"}},
+		{args: []string{"--vrebose"}, suggestions: []string{"--verbose"}},
+		{args: []string{"--colour"}, suggestions: []string{"--color"}},
+		{args: []string{"--no-colr"}, suggestions: []string{"--no-color"}},
+		{args: []string{"--q"}, suggestions: []string{"-q", "--quiet"}},
+		{args: []string{"-verbose"}, suggestions: []string{"--verbose"}},
+		{args: []string{"--xyz"}, suggestions: nil},
+		{args: []string{"--verbos"}, disable: true, suggestions: nil},
+		{args: []string{"--verboes"}, distance: 1, suggestions: nil},
+		{args: []string{"init", "--sharde"}, suggestions: []string{"--shared"}},
+		{args: []string{"init", "--verbos"}, suggestions: nil},
+	}
+
+	for i, v := range data {
+		fs := NewFlagSet("suggestions test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.SetSuggestions(!v.disable)
+		fs.SetSuggestionDistance(v.distance)
+		fs.Bool("verbose", 'v', false, "", nil)
+		fs.Bool("quiet", 'q', false, "", nil)
+		fs.Bool("color", -1, false, "", nil)
+		fs.Lookup("color").SetNegatable(true)
+		fs.BoolSubCommand("init", -1, "",
+			fs.BoolSubFlag("shared", -1, false, "", nil),
+		)
+
+		err := fs.Parse(v.args)
+		var e *UnknownFlagError
+		if !errors.As(err, &e) {
+			t.Errorf(" %d: expected UnknownFlagError; got %v\n", i, err)
+			continue
+		}
+		if fmt.Sprint(e.Suggestions) != fmt.Sprint(v.suggestions) {
+			t.Errorf(" %d: got: %q, want: %q\n", i, e.Suggestions, v.suggestions)
+		}
+	}
+
+	fs := NewFlagSet("suggestions test", ContinueOnError)
+	fs.BoolSubCommand("init", -1, "")
+	fs.BoolSubCommand("install", -1, "")
+	fs.BoolSubCommand("log", -1, "")
+	if got := fs.suggestCommands("inti"); fmt.Sprint(got) != "[init]" {
+		t.Errorf("suggestCommands - got: %q, want: %q", got, []string{"init"})
+	}
+	if got := fs.suggestCommands("ins"); fmt.Sprint(got) != "[init install]" {
+		t.Errorf("suggestCommands - got: %q, want: %q", got, []string{"init", "install"})
+	}
+
+	err := &UnknownFlagError{Name: "colour", Suggestions: []string{"--color", "--no-color"}}
+	expect := "unrecognized option `--colour'; did you mean `--color' or `--no-color'?"
+	if err.Error() != expect {
+		t.Errorf("got: %q, want: %q", err.Error(), expect)
+	}
+}
+
+func TestChanged(t *testing.T) {
+	newFlagSet := func() *FlagSet {
+		fs := NewFlagSet("changed test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.BoolSubCommand("serve", -1, "",
+			fs.BoolSubFlag("verbose", 'v', false, "", nil),
+			fs.IntSubFlag("workers", 'w', 1, "", nil),
+		)
+		fs.Int("port", 'p', 8080, "", nil)
+		fs.Bool("verbose", 'v', false, "", nil)
+		fs.String("host", -1, "", "", nil)
+		return fs
+	}
+
+	fs := newFlagSet()
+	if err := fs.Parse([]string{"-v", "--port=8080", "-vv", "serve", "-v", "--workers", "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if fs.NFlag() != 4 {
+		t.Errorf("NFlag - got: %d, want: %d", fs.NFlag(), 4)
+	}
+	if !fs.Changed("port") || fs.Changed("host") || !fs.Changed("workers") || !fs.Changed("w") {
+		t.Errorf("Changed - got: port=%t host=%t workers=%t", fs.Changed("port"), fs.Changed("host"), fs.Changed("workers"))
+	}
+	// the sub-flag of the selected subcommand, not the top-level flag
+	if n := fs.Occurrences("verbose"); n != 1 {
+		t.Errorf("Occurrences - got: %d, want: %d", n, 1)
+	}
+
+	var visited []string
+	fs.Visit(func(depth int, flag *Flag) {
+		visited = append(visited, fmt.Sprintf("%d:%s", depth, flag.Name))
+	})
+	expect := "[1:port 1:verbose 2:verbose 2:workers]"
+	if fmt.Sprint(visited) != expect {
+		t.Errorf("Visit - got: %s, want: %s", fmt.Sprint(visited), expect)
+	}
+
+	for i, v := range []struct {
+		args []string
+		name string
+		n    int
+	}{
+		{args: []string{"-v", "-vv", "serve"}, name: "verbose", n: 0},
+		{args: []string{"-v", "-vv"}, name: "v", n: 3},
+	} {
+		fs := newFlagSet()
+		if err := fs.Parse(v.args); err != nil || fs.Occurrences(v.name) != v.n {
+			t.Errorf(" %d: Occurrences - got: %v, %d, want: %d", i, err, fs.Occurrences(v.name), v.n)
+		}
+	}
+
+	fs = NewFlagSet("changed test", ContinueOnError)
+	fs.Int("port", 'p', 8080, "", nil)
+	if fs.Parse(nil); fs.NFlag() != 0 || fs.Changed("port") {
+		t.Errorf("NFlag - got: %d, want: %d", fs.NFlag(), 0)
+	}
+}
+
+func TestSet(t *testing.T) {
+	var called int
+	ResetForTesting(nil)
+	port := Int("port", 'p', 8080, "", func(v Value) error {
+		called++
+		if v.Get().(int) < 1 {
+			return fmt.Errorf("invalid port %s", v)
+		}
+		return nil
+	})
+	verbose := Bool("verbose", 'v', false, "", nil)
+	color := String("color", -1, "auto", "", nil)
+	CommandLine.Lookup("color").SetOptionalValue("always")
+
+	if err := Set("port", "9090"); err != nil || *port != 9090 || called != 1 {
+		t.Errorf("Set(port) - got: (%v, %d, %d)", err, *port, called)
+	}
+	if err := Set("p", "0"); err == nil || called != 2 {
+		t.Errorf("Set(p) - expected callback error; got: (%v, %d)", err, called)
+	} else if e, ok := err.(*CallbackError); !ok || e.Name != "port" {
+		t.Errorf("Set(p) - expected CallbackError; got: %T", err)
+	}
+	if err := Set("port", "x"); err == nil || !errors.Is(err, ErrParse) {
+		t.Errorf("Set(port) - expected parse error; got: %v", err)
+	}
+	if err := Set("v", ""); err != nil || !*verbose {
+		t.Errorf("Set(v) - got: (%v, %t)", err, *verbose)
+	}
+	if err := Set("verbose", "false"); err != nil || *verbose {
+		t.Errorf("Set(verbose) - got: (%v, %t)", err, *verbose)
+	}
+	if err := Set("color", ""); err != nil || *color != "always" {
+		t.Errorf("Set(color) - got: (%v, %q)", err, *color)
+	}
+	var unknown *UnknownFlagError
+	if err := Set("unknown", "1"); !errors.As(err, &unknown) {
+		t.Errorf("Set(unknown) - expected UnknownFlagError; got: %v", err)
+	}
+	if !Changed("port") || !Changed("verbose") || Occurrences("verbose") != 2 || NFlag() != 3 {
+		t.Errorf("Changed - got: (%t, %t, %d, %d)", Changed("port"), Changed("verbose"), Occurrences("verbose"), NFlag())
+	}
+}
+
+func TestReparse(t *testing.T) {
+	var log, oneline bool
+	fs := NewFlagSet("reparse test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	verbose := fs.Bool("verbose", 'v', false, "", nil)
+	fs.BoolVarSubCommand(&log, "log", -1, "",
+		fs.BoolVarSubFlag(&oneline, "oneline", -1, false, "", nil),
+	)
+
+	args := []string{"log", "--oneline", "arg"}
+	for i := 0; i < 2; i++ {
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		if !log || !oneline || fs.CommandPath() != "reparse test log" || fs.NArg() != 1 {
+			t.Errorf(" %d: got: (%t, %t, %q, %q)", i, log, oneline, fs.CommandPath(), fs.Args())
+		}
+	}
+	if fmt.Sprint(args) != "[log --oneline arg]" {
+		t.Errorf("arguments were modified: %q", args)
+	}
+	if fs.Lookup("verbose") == nil || fs.Lookup("log") == nil || fs.Lookup("oneline") != nil {
+		t.Error("Lookup must find the top-level flags after Parse")
+	}
+
+	// the top-level flags are available again, and the values of the last
+	// Parse, including the subcommand selected, are restored to the defaults
+	for i := 0; i < 2; i++ {
+		if err := fs.Parse([]string{"-v"}); err != nil || !*verbose || log || oneline {
+			t.Errorf(" %d: got: (%v, %t, %t, %t)", i, err, *verbose, log, oneline)
+		}
+	}
+	tags := fs.StringSlice("tag", -1, []string{"x"}, "", nil)
+	labels := fs.StringToString("label", -1, nil, "", nil)
+	for i := 0; i < 2; i++ {
+		if err := fs.Parse([]string{"--tag", "a", "--label", "k=v"}); err != nil || fmt.Sprint(*tags, *labels) != "[a] map[k:v]" {
+			t.Errorf(" %d: got: (%v, %v, %v)", i, err, *tags, *labels)
+		}
+	}
+	if fs.Parse(nil); fmt.Sprint(*tags, *labels) != "[x] map[]" {
+		t.Errorf("defaults - got: (%v, %v)", *tags, *labels)
+	}
+
+	fs.Parse([]string{"log", "--oneline"})
+	fs.Reset()
+	if fs.Parsed() || fs.CommandPath() != "reparse test" || fs.NFlag() != 0 || fs.NArg() != 0 {
+		t.Errorf("Reset - got: (%t, %q, %d, %d)", fs.Parsed(), fs.CommandPath(), fs.NFlag(), fs.NArg())
+	}
+	if err := fs.Set("verbose", "false"); err != nil || *verbose {
+		t.Errorf("Set after Reset - got: (%v, %t)", err, *verbose)
+	}
+
+	// the values set before Parse, by Set or by the program, are kept
+	var port int
+	fs.IntVar(&port, "port", -1, 80, "", nil)
+	fs.Reset()
+	port = 9000
+	if err := fs.Set("tag", "y"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := fs.Parse([]string{"x"}); err != nil || port != 9000 || fmt.Sprint(*tags) != "[y]" || !fs.Changed("tag") {
+			t.Errorf(" %d: Set before Parse - got: (%v, %d, %v, %t)", i, err, port, *tags, fs.Changed("tag"))
+		}
+	}
+	fs.Parse([]string{"--port", "1"})
+	if fs.Parse(nil); port != 80 || fmt.Sprint(*tags) != "[y]" || fs.Changed("port") {
+		t.Errorf("Parse after Parse - got: (%d, %v, %t)", port, *tags, fs.Changed("port"))
+	}
+}
+
+type commandTestKey struct{}
+
+func TestCommand(t *testing.T) {
+	var called []string
+	var force bool
+	handler := func(name string) func(context.Context, []string) error {
+		return func(ctx context.Context, args []string) error {
+			called = append(called, fmt.Sprintf("%s%q", name, args))
+			if ctx.Value(commandTestKey{}) != "value" {
+				t.Errorf("%s: context was not passed", name)
+			}
+			if name == "fail" {
+				return errors.New("failed")
+			}
+			return nil
+		}
+	}
+
+	fs := NewFlagSet("command test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() { called = append(called, "usage") }
+	fs.Run = handler("root")
+	fs.Bool("verbose", 'v', false, "", nil)
+	remote := fs.AddCommand("remote", 'r', "", nil,
+		fs.BoolSubFlag("all", 'a', false, "", nil),
+	)
+	remote.AddCommand("add", -1, "", handler("add"),
+		fs.BoolVarSubFlag(&force, "force", 'f', false, "", nil),
+	)
+	remote.AddCommand("fail", -1, "", handler("fail"))
+	fs.AddCommand("log", -1, "", handler("log"))
+
+	if remote.Name() != "remote" || remote.Flag() != fs.Lookup("remote") || remote.Lookup("add") == nil {
+		t.Error("Command - unexpected definition")
+	}
+
+	data := []struct {
+		args   []string
+		called string
+		err    string
+	}{
+		{args: []string{"a", "-v"}, called: `[root["a"]]`},
+		{args: []string{"log", "x", "y"}, called: `[log["x" "y"]]`},
+		{args: []string{"-v", "remote", "-a", "add", "-f", "origin"}, called: `[add["origin"]]`},
+		{args: []string{"r", "fail"}, called: `[fail[]]`, err: "failed"},
+		{args: []string{"remote"}, called: `[usage]`, err: ErrHelp.Error()},
+		{args: []string{"remote", "ad"}, called: `[]`, err: "unknown command `ad'; did you mean `add'?"},
+		{args: []string{"--unknown"}, called: `[]`, err: "unrecognized option `--unknown'"},
+	}
+
+	ctx := context.WithValue(context.Background(), commandTestKey{}, "value")
+	for i, v := range data {
+		called = nil
+		err := fs.Execute(ctx, v.args)
+		if fmt.Sprint(called) != v.called {
+			t.Errorf(" %d: called - got: %s, want: %s", i, fmt.Sprint(called), v.called)
+		}
+		if v.err == "" && err != nil || v.err != "" && (err == nil || err.Error() != v.err) {
+			t.Errorf(" %d: error - got: %v, want: %q", i, err, v.err)
+		}
+	}
+	if err := fs.Execute(ctx, []string{"remote", "add", "-f", "origin"}); err != nil || !force {
+		t.Errorf("sub-flag of a command was not set: %v", err)
+	}
+}
+
+func TestPersistent(t *testing.T) {
+	var verbose bool
+	var out bytes.Buffer
+	fs := NewFlagSet("persistent test", ContinueOnError)
+	fs.SetOutput(&out)
+	fs.BoolVar(&verbose, "verbose", 'v', false, "verbose output", nil)
+	fs.SetPersistent("verbose")
+	fs.String("config", 'c', "", "", nil)
+	remote := fs.AddCommand("remote", -1, "", nil,
+		fs.BoolSubFlag("all", 'a', false, "show all", nil),
+	)
+	remote.AddCommand("add", -1, "", nil)
+
+	data := []struct {
+		args    []string
+		verbose bool
+		err     string
+	}{
+		{args: []string{"remote", "--verbose"}, verbose: true},
+		{args: []string{"remote", "add", "-v"}, verbose: true},
+		{args: []string{"remote", "add", "--verb"}, err: "unrecognized option `--verb'; did you mean `--verbose'?"},
+		{args: []string{"remote", "--config", "x"}, err: "unrecognized option `--config'"},
+	}
+
+	for i, v := range data {
+		verbose = false
+		err := fs.Parse(v.args)
+		if v.err == "" && err != nil || v.err != "" && (err == nil || err.Error() != v.err) {
+			t.Errorf(" %d: error - got: %v, want: %q", i, err, v.err)
+		}
+		if verbose != v.verbose {
+			t.Errorf(" %d: verbose - got: %v, want: %v", i, verbose, v.verbose)
+		}
+	}
+
+	fs.Parse([]string{"remote"})
+	out.Reset()
+	fs.PrintCommandDefaults()
+	if s := out.String(); !strings.Contains(s, "Global Options:\n") || !strings.Contains(s, "--verbose") || strings.Contains(s, "--config") {
+		t.Errorf("PrintCommandDefaults - got: %q", s)
+	}
+
+	redefine := func(name string, alias rune) (msg string) {
+		defer func() {
+			if r := recover(); r != nil {
+				msg = fmt.Sprint(r)
+			}
+		}()
+		fs := NewFlagSet("persistent test", ContinueOnError)
+		fs.SetOutput(ioutil.Discard)
+		fs.Bool("verbose", 'v', false, "", nil)
+		fs.SetPersistent("verbose")
+		remote := fs.AddCommand("remote", -1, "", nil)
+		remote.AddCommand("rm", -1, "", nil, fs.BoolSubFlag(name, alias, false, "", nil))
+		return ""
+	}
+	if msg := redefine("verbose", -1); msg != "persistent flag redefined: verbose" {
+		t.Errorf("redefined - got: %q", msg)
+	}
+	if msg := redefine("force", 'v'); msg != "persistent flag redefined: -v" {
+		t.Errorf("redefined alias - got: %q", msg)
+	}
+	if msg := redefine("force", 'f'); msg != "" {
+		t.Errorf("not redefined - got: %q", msg)
+	}
+
+	// a flag that is not persistent may be shadowed by a sub-flag
+	var top, sub string
+	shadow := NewFlagSet("persistent test", ContinueOnError)
+	shadow.SetOutput(ioutil.Discard)
+	shadow.StringVar(&top, "config", 'c', "", "", nil)
+	shadow.AddCommand("run", -1, "", nil, shadow.StringVarSubFlag(&sub, "config", 'c', "", "", nil))
+	if err := shadow.Parse([]string{"-c", "a", "run", "-c", "b"}); err != nil || top != "a" || sub != "b" {
+		t.Errorf("shadowed - got: %v, %q, %q", err, top, sub)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	var out bytes.Buffer
+	var called bool
+	fs := NewFlagSet("help test", ContinueOnError)
+	fs.SetOutput(&out)
+	fs.SetHelpCommand(true)
+	fs.Bool("verbose", 'v', false, "verbose output", nil)
+	fs.SetPersistent("verbose")
+	remote := fs.AddCommand("remote", 'r', "manage remotes", func(context.Context, []string) error {
+		called = true
+		return nil
+	},
+		fs.BoolSubFlag("all", 'a', false, "show all remotes", nil),
+	)
+	remote.AddCommand("add", -1, "add a remote", nil, fs.BoolSubFlag("fetch", 'f', false, "fetch the remote", nil))
+
+	data := []struct {
+		args     []string
+		contains []string
+		excludes []string
+		err      string
+	}{
+		{args: []string{"help"}, contains: []string{"Usage: help test\n", "remote", "help"}, err: ErrHelp.Error()},
+		{args: []string{"help", "remote"}, contains: []string{"Usage: help test remote\n", "--all", "add", "Global Options:", "--verbose"}, excludes: []string{"--fetch"}, err: ErrHelp.Error()},
+		{args: []string{"help", "r", "add"}, contains: []string{"Usage: help test remote add\n", "--fetch"}, excludes: []string{"--all"}, err: ErrHelp.Error()},
+		{args: []string{"remote", "add", "--help"}, contains: []string{"Usage: help test remote add\n", "--fetch"}, excludes: []string{"--all"}, err: ErrHelp.Error()},
+		{args: []string{"remote", "-h"}, contains: []string{"Usage: help test remote\n", "--all"}, err: ErrHelp.Error()},
+		{args: []string{"help", "remote", "ad"}, err: "unknown command `ad'; did you mean `add'?"},
+	}
+
+	for i, v := range data {
+		out.Reset()
+		called = false
+		err := fs.Execute(context.Background(), v.args)
+		if v.err == "" && err != nil || v.err != "" && (err == nil || err.Error() != v.err) {
+			t.Errorf(" %d: error - got: %v, want: %q", i, err, v.err)
+		}
+		if called {
+			t.Errorf(" %d: command was executed", i)
+		}
+		for _, s := range v.contains {
+			if !strings.Contains(out.String(), s) {
+				t.Errorf(" %d: usage - got: %q, want: contains %q", i, out.String(), s)
+			}
+		}
+		for _, s := range v.excludes {
+			if strings.Contains(out.String(), s) {
+				t.Errorf(" %d: usage - got: %q, want: not contains %q", i, out.String(), s)
+			}
+		}
+	}
+
+	fs.SetHelpCommand(false)
+	if fs.Lookup("help") != nil {
+		t.Error("help command was not removed")
+	}
+}
+
+func TestCommandFlags(t *testing.T) {
+	var tags flagVar
+	fs := NewFlagSet("command flags test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	serve := fs.AddCommand("serve", -1, "", nil,
+		fs.SubFlag(&tags, "tag", 't', "", nil),
+		fs.SubCommand("tls", -1, "",
+			fs.StringSubFlag("cert", -1, "", "", nil),
+		),
+	)
+	port := serve.Flags().Int("port", 'p', 80, "", nil)
+	timeout := serve.Flags().Duration("timeout", -1, time.Second, "", nil)
+	child := serve.Flags().AddCommand("child", -1, "", nil)
+	depth := child.Flags().Uint("depth", 'd', 0, "", nil)
+
+	if serve.Lookup("port") == nil || child.Flag() != serve.Lookup("child") {
+		t.Error("Command.Flags - flags were not defined on the command")
+	}
+	if fs.Lookup("port") != nil {
+		t.Error("Command.Flags - flag was defined on the top level")
+	}
+
+	data := []struct {
+		args []string
+		path string
+		want string
+	}{
+		{args: []string{"serve", "-p", "8080", "--timeout", "3s", "-t", "a", "--tag=b"}, path: "command flags test serve", want: "8080 3s [a b] 0"},
+		{args: []string{"serve", "child", "-d", "2"}, path: "command flags test serve child", want: "80 1s [] 2"},
+		{args: []string{"serve", "tls", "--cert", "x"}, path: "command flags test serve tls", want: "80 1s [] 0"},
+	}
+
+	for i, v := range data {
+		*port, *timeout, *depth, tags = 80, time.Second, 0, nil
+		if err := fs.Parse(v.args); err != nil {
+			t.Errorf(" %d: %v", i, err)
+		}
+		if fs.CommandPath() != v.path {
+			t.Errorf(" %d: path - got: %s, want: %s", i, fs.CommandPath(), v.path)
+		}
+		if got := fmt.Sprintf("%d %v %s %d", *port, *timeout, tags.String(), *depth); got != v.want {
+			t.Errorf(" %d: values - got: %s, want: %s", i, got, v.want)
+		}
+	}
+
+	if err := fs.Parse([]string{"-p", "1"}); err == nil {
+		t.Error("flag of a command was accepted at the top level")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("redefinition of a flag of a command did not panic")
+		}
+	}()
+	serve.Flags().String("port", -1, "", "", nil)
+}
+
+func TestArguments(t *testing.T) {
+	var out bytes.Buffer
+	var src []string
+	var name string
+	var count int
+	fs := NewFlagSet("args test", ContinueOnError)
+	fs.SetOutput(&out)
+	fs.ArgVar(newStringValue(&name, ""), "name", "").SetRequired(false)
+	cp := fs.AddCommand("cp", -1, "", nil)
+	cp.Flags().ArgVar(newStringSliceValue(&src, nil), "src", "source files").SetVariadic(true)
+	dst := cp.Flags().StringArg("dst", "destination")
+	repeat := fs.AddCommand("repeat", -1, "", nil)
+	repeat.Flags().ArgVar(newIntValue(&count, 0), "count", "")
+
+	data := []struct {
+		args []string
+		want string
+		err  string
+	}{
+		{args: []string{}, want: " [] 0"},
+		{args: []string{"x"}, want: "x [] 0"},
+		{args: []string{"x", "y"}, want: " [] 0", err: "unexpected argument `y'"},
+		{args: []string{"cp", "a", "b", "c"}, want: " [a b] 0"},
+		{args: []string{"cp", "a", "--", "-b"}, want: " [a] 0"},
+		{args: []string{"cp", "a"}, want: " [] 0", err: "missing argument <dst>"},
+		{args: []string{"cp", "a", "b", "c"}, want: " [a b] 0"},
+		{args: []string{"cp"}, want: " [] 0", err: "missing argument <src>"},
+		{args: []string{"repeat", "3"}, want: " [] 3"},
+		{args: []string{"repeat", "x"}, want: " [] 0", err: "invalid value `x' for argument <count>: parse error"},
+	}
+
+	for i, v := range data {
+		err := fs.Parse(v.args)
+		if v.err == "" && err != nil || v.err != "" && (err == nil || err.Error() != v.err) {
+			t.Errorf(" %d: error - got: %v, want: %q", i, err, v.err)
+		}
+		if got := fmt.Sprintf("%s %v %d", name, src, count); got != v.want {
+			t.Errorf(" %d: values - got: %s, want: %s", i, got, v.want)
+		}
+	}
+	if err := fs.Parse([]string{"cp", "a", "b"}); err != nil || *dst != "b" {
+		t.Errorf("dst - got: %q, %v, want: %q", *dst, err, "b")
+	}
+
+	out.Reset()
+	fs.usage()
+	if s := out.String(); !strings.Contains(s, "Usage: args test cp <src>... <dst>\n") || !strings.Contains(s, "Arguments:\n") || !strings.Contains(s, "destination") {
+		t.Errorf("usage - got: %q", s)
+	}
+}
+
+func TestCommandAliases(t *testing.T) {
+	var out bytes.Buffer
+	var called []string
+	handler := func(name string) func(context.Context, []string) error {
+		return func(context.Context, []string) error {
+			called = append(called, name)
+			return nil
+		}
+	}
+	fs := NewFlagSet("aliases test", ContinueOnError)
+	fs.SetOutput(&out)
+	fs.SetCommandAbbreviation(true)
+	fs.AddCommand("rm", -1, "remove files", handler("rm")).SetAliases("remove", "delete")
+	fs.AddCommand("debug", -1, "", handler("debug")).Flag().SetHidden(true)
+	fs.AddCommand("del", -1, "", handler("del")).SetAliases("erase").Flag().SetDeprecated("use `rm' instead")
+	fs.Bool("old", 'o', false, "", nil)
+	fs.Lookup("old").SetDeprecated("use `--new' instead")
+	fs.Bool("secret", -1, false, "", nil)
+	fs.Lookup("secret").SetHidden(true)
+
+	data := []struct {
+		args   []string
+		called string
+		output string
+		err    string
+	}{
+		{args: []string{"rm"}, called: "[rm]"},
+		{args: []string{"remove"}, called: "[rm]"},
+		{args: []string{"delete"}, called: "[rm]"},
+		{args: []string{"debug"}, called: "[debug]"},
+		{args: []string{"del"}, called: "[del]", output: "command `del' is deprecated; use `rm' instead\n"},
+		{args: []string{"deb"}, called: "[]", err: "unknown command `deb'; did you mean `del'?"},
+		{args: []string{"erase"}, called: "[del]", output: "command `erase' is deprecated; use `rm' instead\n"},
+		{args: []string{"-o", "rm"}, called: "[rm]", output: "option `-o' is deprecated; use `--new' instead\n"},
+		{args: []string{"remov"}, called: "[rm]"},
+		{args: []string{"dele"}, called: "[rm]"},
+		{args: []string{"remvoe"}, called: "[]", err: "unknown command `remvoe'; did you mean `remove'?"},
+		{args: []string{"--secre"}, called: "[]", err: "unrecognized option `--secre'"},
+	}
+
+	for i, v := range data {
+		out.Reset()
+		called = nil
+		err := fs.Execute(context.Background(), v.args)
+		if v.err == "" && err != nil || v.err != "" && (err == nil || err.Error() != v.err) {
+			t.Errorf(" %d: error - got: %v, want: %q", i, err, v.err)
+		}
+		if fmt.Sprint(called) != v.called {
+			t.Errorf(" %d: called - got: %s, want: %s", i, fmt.Sprint(called), v.called)
+		}
+		if v.err == "" && out.String() != v.output {
+			t.Errorf(" %d: output - got: %q, want: %q", i, out.String(), v.output)
+		}
+	}
+
+	out.Reset()
+	fs.PrintDefaults()
+	if s := out.String(); !strings.Contains(s, "rm, remove, delete") || strings.Contains(s, "debug") || strings.Contains(s, "secret") {
+		t.Errorf("PrintDefaults - got: %q", s)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("alias of an existing command did not panic")
+		}
+	}()
+	fs.AddCommand("ls", -1, "", nil).SetAliases("del")
+}
+
+func TestSliceFlags(t *testing.T) {
+	fs := NewFlagSet("slice test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	tags := fs.StringSlice("tag", 't', []string{"default"}, "", nil)
+	fs.Lookup("tag").SetSeparator(',')
+	ints := fs.IntSlice("int", -1, nil, "", nil)
+	int64s := fs.Int64Slice("int64", -1, nil, "", nil)
+	uints := fs.UintSlice("uint", -1, nil, "", nil)
+	floats := fs.Float64Slice("float", -1, nil, "", nil)
+	durations := fs.DurationSlice("duration", -1, nil, "", nil)
+	bools := fs.BoolSlice("bool", -1, nil, "", nil)
+
+	if err := fs.Parse([]string{
+		"--tag", "a", "-t", `b,"c,d"`, "--tag=e",
+		"--int", "1", "--int=-2", "--int64", "3", "--uint", "4", "--float", "1.5",
+		"--duration", "1s", "--duration", "2m", "--bool", "true", "--bool", "false",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	data := []struct {
+		name string
+		got  interface{}
+		want string
+		str  string
+		typ  string
+	}{
+		{name: "tag", got: *tags, want: "[a b c,d e]", str: `[a,b,"c,d",e]`, typ: "[]string"},
+		{name: "int", got: *ints, want: "[1 -2]", str: "[1,-2]", typ: "[]int"},
+		{name: "int64", got: *int64s, want: "[3]", str: "[3]", typ: "[]int"},
+		{name: "uint", got: *uints, want: "[4]", str: "[4]", typ: "[]uint"},
+		{name: "float", got: *floats, want: "[1.5]", str: "[1.5]", typ: "[]float"},
+		{name: "duration", got: *durations, want: "[1s 2m0s]", str: "[1s,2m0s]", typ: "[]duration"},
+		{name: "bool", got: *bools, want: "[true false]", str: "[true,false]", typ: "[]bool"},
+	}
+	for i, v := range data {
+		flag := fs.Lookup(v.name)
+		if fmt.Sprint(v.got) != v.want {
+			t.Errorf(" %d: value - got: %v, want: %s", i, v.got, v.want)
+		}
+		if flag.Value.String() != v.str {
+			t.Errorf(" %d: String - got: %s, want: %s", i, flag.Value.String(), v.str)
+		}
+		if ValueType(flag) != v.typ {
+			t.Errorf(" %d: ValueType - got: %s, want: %s", i, ValueType(flag), v.typ)
+		}
+	}
+
+	if name, _ := UnquoteUsage(fs.Lookup("tag")); name != "strings" {
+		t.Errorf("UnquoteUsage - got: %s, want: strings", name)
+	}
+	if err := fs.Parse([]string{"--int", "x"}); err == nil || !errors.Is(err, ErrParse) {
+		t.Errorf("invalid element - got: %v", err)
+	}
+	fs.Lookup("int").SetSeparator(',')
+	if err := fs.Parse([]string{"--int", "1", "--int", "2,x"}); !errors.Is(err, ErrParse) || fmt.Sprint(*ints) != "[1]" {
+		t.Errorf("partly invalid argument - got: %v, %v, want: [1]", err, *ints)
+	}
+	for i, v := range []string{`"a`, `"a"b,c`} {
+		if err := fs.Parse([]string{"-t", v}); err == nil || !errors.Is(err, ErrParse) {
+			t.Errorf(" %d: invalid quoting - got: %v", i, err)
+		}
+	}
+	for i, v := range []struct{ arg, want string }{
+		{arg: "a,b\nc,d", want: "[a b\nc d]"},
+		{arg: `5" screen,b`, want: `[5" screen b]`},
+		{arg: `"a""b",,""`, want: `[a"b  ]`},
+	} {
+		if err := fs.Parse([]string{"-t", v.arg}); err != nil || fmt.Sprint(*tags) != v.want {
+			t.Errorf(" %d: got: %v, %q, want: %q", i, err, *tags, v.want)
+		}
+	}
+}
+
+func TestMapFlags(t *testing.T) {
+	fs := NewFlagSet("map test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	labels := fs.StringToString("label", 'l', map[string]string{"b": "2", "a": "1"}, "", nil)
+	limits := fs.StringToInt("limit", -1, nil, "", nil)
+	fs.Lookup("limit").SetUniqueKeys(true)
+	timeouts := fs.StringToDuration("timeout", -1, nil, "", nil)
+
+	if v := fs.Lookup("label").DefValue; v != "[a=1,b=2]" {
+		t.Errorf("DefValue - got: %s, want: [a=1,b=2]", v)
+	}
+	if ValueType(fs.Lookup("limit")) != "map[string]int" {
+		t.Errorf("ValueType - got: %s", ValueType(fs.Lookup("limit")))
+	}
+	if name, _ := UnquoteUsage(fs.Lookup("timeout")); name != "key=duration" {
+		t.Errorf("UnquoteUsage - got: %s, want: key=duration", name)
+	}
+
+	if err := fs.Parse([]string{
+		"--label", "env=prod", "-l", "team=infra", "--label=env=dev", "--label", "empty=",
+		"--limit", "cpu=2", "--timeout", "read=3s",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if s := fs.Lookup("label").Value.String(); s != "[empty=,env=dev,team=infra]" {
+		t.Errorf("label - got: %s (%v)", s, *labels)
+	}
+	if (*limits)["cpu"] != 2 || (*timeouts)["read"] != 3*time.Second {
+		t.Errorf("values - got: %v, %v", *limits, *timeouts)
+	}
+
+	data := []struct {
+		args   []string
+		err    string
+		reason error
+	}{
+		{args: []string{"--label", "env"}, err: "invalid value `env' for option `--label': expected a key=value pair", reason: ErrPair},
+		{args: []string{"--label", "=x"}, err: "invalid value `=x' for option `--label': expected a key=value pair", reason: ErrPair},
+		{args: []string{"--limit", "cpu=x"}, err: "invalid value `cpu=x' for option `--limit': parse error", reason: ErrParse},
+		{args: []string{"--limit", "mem=1", "--limit", "mem=2"}, err: "invalid value `mem=2' for option `--limit': duplicate key `mem'", reason: ErrDuplicateKey},
+	}
+	for i, v := range data {
+		err := fs.Parse(v.args)
+		var e *InvalidValueError
+		if err == nil || err.Error() != v.err || !errors.As(err, &e) || !errors.Is(err, v.reason) {
+			t.Errorf(" %d: error - got: %v, want: %q", i, err, v.err)
+		}
+	}
+}
+
+func TestEnumFlags(t *testing.T) {
+	var out bytes.Buffer
+	fs := NewFlagSet("enum test", ContinueOnError)
+	fs.SetOutput(&out)
+	format := fs.Enum("format", 'f', "table", []string{"json", "yaml", "table"}, "output format", nil)
+	fs.Lookup("format").SetChoiceUsage("json", "JSON output").SetChoiceUsage("table", "aligned columns")
+	color := fs.Enum("color", -1, "auto", []string{"always", "never", "auto"}, "", nil)
+	fs.Lookup("color").SetCaseInsensitive(true)
+
+	data := []struct {
+		args   []string
+		format string
+		color  string
+		err    string
+	}{
+		{args: []string{}, format: "table", color: "auto"},
+		{args: []string{"-f", "json", "--color=NEVER"}, format: "json", color: "never"},
+		{args: []string{"--format", "JSON"}, format: "table", color: "auto", err: "invalid value `JSON' for option `--format': must be one of: json, yaml, table"},
+		{args: []string{"--color", "x"}, format: "table", color: "auto", err: "invalid value `x' for option `--color': must be one of: always, never, auto"},
+	}
+	for i, v := range data {
+		*format, *color = "table", "auto"
+		err := fs.Parse(v.args)
+		if v.err == "" && err != nil || v.err != "" && (err == nil || err.Error() != v.err) {
+			t.Errorf(" %d: error - got: %v, want: %q", i, err, v.err)
+		}
+		if *format != v.format || *color != v.color {
+			t.Errorf(" %d: values - got: %s %s, want: %s %s", i, *format, *color, v.format, v.color)
+		}
+	}
+
+	if err := fs.Parse([]string{"--format", "xml"}); !errors.Is(err, ErrChoice) {
+		t.Errorf("invalid choice - got: %v, want: %v", err, ErrChoice)
+	}
+	if c := fs.Lookup("format").Choices(); fmt.Sprint(c) != "[json yaml table]" {
+		t.Errorf("Choices - got: %v", c)
+	}
+
+	func() {
+		defer func() {
+			if r := recover(); r == nil || !strings.Contains(out.String(), "default value of flag level is not one of the choices: trace") {
+				t.Errorf("invalid default - got: %v, %q", r, out.String())
+			}
+		}()
+		fs.Enum("level", -1, "trace", []string{"debug", "info"}, "", nil)
+	}()
+
+	out.Reset()
+	fs.PrintDefaults()
+	s := out.String()
+	for _, want := range []string{"--format {json|yaml|table}", "output format\n", "json   JSON output\n", "table  aligned columns\n"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("PrintDefaults - got: %q, want: contains %q", s, want)
+		}
+	}
+}
+
+func TestCountFlags(t *testing.T) {
+	var verbose int
+	fs := NewFlagSet("count test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.SetNegatable(true)
+	fs.CountVar(&verbose, "verbose", 'v', 0, "", nil)
+	quiet := fs.Bool("quiet", 'q', false, "", nil)
+	level := fs.AddCommand("run", -1, "", nil).Flags().Count("level", 'l', 1, "", nil)
+
+	data := []struct {
+		args    []string
+		verbose int
+		level   int
+		err     string
+	}{
+		{args: []string{"-v"}, verbose: 1, level: 1},
+		{args: []string{"-vvv"}, verbose: 3, level: 1},
+		{args: []string{"-vqv", "--verbose"}, verbose: 3, level: 1},
+		{args: []string{"--verbose=3", "-v"}, verbose: 4, level: 1},
+		{args: []string{"-v=2"}, verbose: 2, level: 1},
+		{args: []string{"run", "-ll"}, verbose: 0, level: 3},
+		{args: []string{"--verbose=x"}, verbose: 0, level: 1, err: "invalid value `x' for option `--verbose': parse error"},
+		{args: []string{"--no-verbose"}, verbose: 0, level: 1, err: "unrecognized option `--no-verbose'"},
+	}
+	for i, v := range data {
+		verbose, *level = 0, 1
+		err := fs.Parse(v.args)
+		if v.err == "" && err != nil || v.err != "" && (err == nil || err.Error() != v.err) {
+			t.Errorf(" %d: error - got: %v, want: %q", i, err, v.err)
+		}
+		if verbose != v.verbose || *level != v.level {
+			t.Errorf(" %d: count - got: %d %d, want: %d %d", i, verbose, *level, v.verbose, v.level)
+		}
+	}
+	if err := fs.Parse([]string{"-vqv"}); err != nil || !*quiet || verbose != 2 {
+		t.Errorf("bool option in a cluster with a count - got: %v, %t, %d", err, *quiet, verbose)
+	}
+
+	flag := fs.Lookup("verbose")
+	if name, _ := UnquoteUsage(flag); name != "" || ValueType(flag) != "count" || flag.GetFlagName() != "-v, --verbose" {
+		t.Errorf("usage - got: %q, %s, %s", name, ValueType(flag), flag.GetFlagName())
+	}
+}
@@ -0,0 +1,55 @@
+package flago
+
+import "unicode/utf8"
+
+// SetHelpCommand sets whether the flag set has a "help" command at the top
+// level. "help [command...]" prints the usage message of the commands named,
+// like "command... --help", without parsing the rest of the arguments, and
+// Parse returns ErrHelp, so Execute runs no handler.
+func (f *FlagSet) SetHelpCommand(enable bool) {
+	if !enable {
+		if f.helpCommand != nil && f.flags["help"] == f.helpCommand {
+			delete(f.flags, "help")
+		}
+		f.helpCommand = nil
+		return
+	}
+	if f.helpCommand == nil {
+		f.helpCommand = f.Var(newBoolValue(new(bool), false), "help", -1, "show help for a command", COMMAND, nil)
+	}
+}
+
+// SetHelpCommand sets whether the command line has a "help" command.
+func SetHelpCommand(enable bool) {
+	CommandLine.SetHelpCommand(enable)
+}
+
+// help selects the subcommands named by the remaining arguments, and prints
+// the usage message of the deepest one.
+func (f *FlagSet) help() error {
+	names := f.args[f.index:]
+	index := f.index + f.cuts
+	f.index = len(f.args)
+
+	for i, name := range names {
+		flags := f.scopeFlags()
+		flag, ok := flags[name]
+		if !ok && utf8.RuneCountInString(name) == 1 {
+			r, _ := utf8.DecodeRuneInString(name)
+			flag, ok = flags[aliasToKey(r)]
+		}
+		if (!ok || !flag.IsSubCommand()) && f.abbrevCommand {
+			flag, _ = f.lookupPrefix(name, true)
+			ok = flag != nil
+		}
+		if !ok || !flag.IsSubCommand() {
+			return f.fail(&UnknownCommandError{
+				Name: name, Token: name, Index: index + i, Suggestions: f.suggestCommands(name),
+			})
+		}
+		f.path = append(f.path, flag)
+	}
+
+	f.usage()
+	return ErrHelp
+}
@@ -3,60 +3,148 @@ package flago
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 )
 
 func (f *FlagSet) cut() string {
 	v := f.args[f.index]
 	f.args = append(f.args[:f.index], f.args[f.index+1:]...)
+	f.cuts++
 	return v
 }
 
-// failf prints to standard error a formatted error and usage message and
-// returns the error.
-func (f *FlagSet) failf(format string, a ...interface{}) error {
-	err := fmt.Errorf(format, a...)
+// fail prints the error to standard error and returns it.
+func (f *FlagSet) fail(err error) error {
 	fmt.Fprintln(f.Output(), err)
 	return err
 }
 
-// setValue sets value, and execute if callback is not nil
-func (f *FlagSet) setValue(flag *Flag, value string, hasValue bool) error {
-	var err error
-	// boolean value is inverted unless a value is explicitly specified with "="
-	if b, ok := flag.Value.(boolFlag); ok && b.IsBoolFlag() {
-		if hasValue {
-			err = flag.Value.Set(value)
-		} else if v, ok := flag.Value.Get().(bool); ok {
-			err = flag.Value.Set(strconv.FormatBool(!v))
-		} else {
-			err = fmt.Errorf("option `--%s' type not a boolean", flag.Name)
+// setValue sets value, taking the next argument if the flag requires it,
+// and execute if callback is not nil. option is the option as it is given,
+// such as "--verb" or "-v".
+func (f *FlagSet) setValue(flag *Flag, option, value string, hasValue bool) error {
+	if !hasValue && !isBoolFlag(flag) && !flag.optional {
+		if f.index >= len(f.args) {
+			return f.fail(&MissingArgumentError{
+				Flag: flag, Name: flag.Name, Alias: flag.Alias, Token: f.token, Index: f.tokenIndex,
+			})
 		}
+		value, hasValue = f.cut(), true
+	}
 
-	} else if hasValue {
-		err = flag.Value.Set(value)
-
-	} else if f.index < len(f.args) {
-		err = flag.Value.Set(f.cut())
+	f.warnDeprecated(flag, option)
+	if err := f.apply(flag, value, hasValue); err != nil {
+		return f.fail(err)
+	}
+	f.markChanged(flag, true)
+	return nil
+}
 
+// warnDeprecated prints the warning of a deprecated flag or sub-command,
+// naming it as it is given.
+func (f *FlagSet) warnDeprecated(flag *Flag, given string) {
+	if flag.deprecated == "" {
+		return
+	}
+	if flag.IsSubCommand() {
+		fmt.Fprintf(f.Output(), "command `%s' is deprecated; %s\n", given, flag.deprecated)
 	} else {
-		err = fmt.Errorf("option `--%s' requires an argument", flag.Name)
+		fmt.Fprintf(f.Output(), "option `%s' is deprecated; %s\n", given, flag.deprecated)
 	}
+}
 
-	// callback function
-	if err == nil && flag.callback != nil {
-		err = flag.callback(flag.Value)
+// apply sets the value of the flag, and execute if callback is not nil.
+// If the value is not given, boolean value is inverted, count value is
+// incremented, and optional value is set to the flag's NoValue.
+func (f *FlagSet) apply(flag *Flag, value string, hasValue bool) error {
+	if !hasValue {
+		c, isCount := flag.Value.(*countValue)
+		switch {
+		case isCount:
+			value = strconv.Itoa(int(*c) + 1)
+		case isNegatable(flag):
+			value = "true"
+		case isBoolFlag(flag):
+			v, ok := flag.Value.Get().(bool)
+			if !ok {
+				return &InvalidValueError{
+					Flag: flag, Name: flag.Name, Alias: flag.Alias, Token: f.token, Index: f.tokenIndex,
+					Err: ErrNotBool,
+				}
+			}
+			value = strconv.FormatBool(!v)
+		case flag.optional:
+			value = flag.NoValue
+		}
 	}
 
-	if err != nil {
-		return f.failf("%s", err)
+	if err := flag.Value.Set(value); err != nil {
+		return &InvalidValueError{
+			Flag: flag, Name: flag.Name, Alias: flag.Alias, Token: f.token, Index: f.tokenIndex,
+			Value: value, Err: err,
+		}
+	}
+
+	// callback function
+	if flag.callback != nil {
+		if err := flag.callback(flag.Value); err != nil {
+			return &CallbackError{
+				Flag: flag, Name: flag.Name, Alias: flag.Alias, Token: f.token, Index: f.tokenIndex,
+				Err: err,
+			}
+		}
 	}
 	return nil
 }
 
+// lookupPrefix returns the flag or subcommand whose name begins with prefix.
+// If the prefix is not unique, it returns nil and the sorted names of the
+// candidates.
+func (f *FlagSet) lookupPrefix(prefix string, command bool) (*Flag, []string) {
+	flags := f.scopeFlags()
+	if !command {
+		flags = f.optionFlags()
+	}
+
+	var flag *Flag
+	var candidates []string
+	unique := true
+	for k, v := range flags {
+		// Excluding a mapping by the short name of the flag, but not the long
+		// aliases of the sub-commands
+		if strings.HasPrefix(k, _ALIAS_PREFIX) || v.IsSubCommand() != command || v.hidden || !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if flag != nil && flag != v {
+			unique = false
+		}
+		flag = v
+		candidates = append(candidates, k)
+	}
+	// the name and the aliases of one sub-command may match together
+	if flag != nil && unique {
+		return flag, nil
+	}
+	sort.Strings(candidates)
+	return nil, candidates
+}
+
 // parseOne parses one flag.
 func (f *FlagSet) parseOne() error {
+	flags := f.scopeFlags()
 	v := f.args[f.index]
+	f.token, f.tokenIndex = v, f.index+f.cuts
+
+	// "--" terminates the options, the remaining arguments are left as they are
+	if v == "--" {
+		f.cut()
+		f.dashAt = f.index
+		f.index = len(f.args)
+		return nil
+	}
 
 	if len(v) > 1 && v[0] == '-' {
 		f.cut()
@@ -70,7 +158,7 @@ func (f *FlagSet) parseOne() error {
 		name := v[n:]
 
 		if len(name) == 0 || name[0] == '-' || name[0] == '=' {
-			return f.failf("invalid syntax as an option `%s'", v)
+			return f.fail(&OptionSyntaxError{Token: v, Index: f.tokenIndex})
 		}
 
 		// when value is specified by "="
@@ -87,86 +175,202 @@ func (f *FlagSet) parseOne() error {
 
 		switch n {
 		case 2: // long option
-			if flag, ok := f.flags[name]; ok && !flag.IsSubCommand() {
-				return f.setValue(flag, value, hasValue)
+			if flag, ok := f.lookupFlag(name); ok {
+				return f.setValue(flag, "--"+name, value, hasValue)
+			}
+			if strings.HasPrefix(name, "no-") {
+				if flag, ok := f.lookupFlag(name[3:]); ok && isNegatable(flag) {
+					if hasValue {
+						return f.fail(&InvalidValueError{
+							Flag: flag, Name: flag.Name, Alias: flag.Alias, Token: v, Index: f.tokenIndex,
+							Value: value, Err: ErrNegated,
+						})
+					}
+					return f.setValue(flag, "--"+name, "false", true)
+				}
 			}
 			if name == "help" {
 				f.usage()
 				return ErrHelp
 			}
-			return f.failf("unrecognized option `--%s'", name)
+			if f.abbrev {
+				flag, candidates := f.lookupPrefix(name, false)
+				if flag != nil {
+					return f.setValue(flag, "--"+name, value, hasValue)
+				}
+				if len(candidates) > 0 {
+					return f.fail(&UnknownFlagError{
+						Name: name, Token: v, Index: f.tokenIndex, Candidates: candidates,
+					})
+				}
+			}
+			return f.fail(&UnknownFlagError{
+				Name: name, Token: v, Index: f.tokenIndex, Suggestions: f.suggestFlags(name, false),
+			})
 
 		case 1: // short option
 			if hasValue {
 				if len(name) == 1 {
-					if flag, ok := f.flags[aliasToKey(rune(name[0]))]; ok && !flag.IsSubCommand() {
-						return f.setValue(flag, value, hasValue)
+					if flag, ok := f.lookupFlag(aliasToKey(rune(name[0]))); ok {
+						return f.setValue(flag, "-"+name, value, hasValue)
 					}
-					return f.failf("unrecognized option `-%s'", name)
+					return f.fail(&UnknownFlagError{Alias: rune(name[0]), Token: v, Index: f.tokenIndex})
 				}
-				return f.failf("unrecognized option `-%s'", name)
+				// "=" may belong to a value attached to an option in the middle,
+				// such as "-ofile=name", so walk the whole argument
+				name = v[1:]
 			}
 
-			for _, r := range name {
-				if flag, ok := f.flags[aliasToKey(r)]; ok && !flag.IsSubCommand() {
-					if err := f.setValue(flag, "", false); err != nil {
-						return err
+			// find all of the options of the cluster before setting any of
+			// them, so that an invalid cluster is rejected as a whole
+			var cluster []*Flag
+			var given []string
+			value, hasValue = "", false
+			for i, r := range name {
+				flag, ok := f.lookupFlag(aliasToKey(r))
+				if !ok {
+					// "-bc=value", equals can not be used with consecutive bool options
+					if r == '=' {
+						return f.fail(&OptionSyntaxError{Token: v, Index: f.tokenIndex})
+					}
+
+					// to output help message
+					if r == 'h' {
+						f.usage()
+						return ErrHelp
+					}
+
+					// "-verbos" may be a mistake of a long option
+					var suggestions []string
+					if len(name) > 1 {
+						suggestions = f.suggestFlags(name, true)
 					}
-					continue
+					return f.fail(&UnknownFlagError{
+						Alias: r, Token: v, Index: f.tokenIndex, Suggestions: suggestions,
+					})
 				}
+				cluster = append(cluster, flag)
+				given = append(given, "-"+string(r))
 
-				// to output help message
-				if r == 'h' {
-					f.usage()
-					return ErrHelp
+				if !isBoolFlag(flag) {
+					// the rest of the argument is the value of the option,
+					// and "-vo=value" is the same as "-vo value"
+					if rest := name[i+utf8.RuneLen(r):]; len(rest) > 0 {
+						value, hasValue = strings.TrimPrefix(rest, "="), true
+					} else if !flag.optional {
+						if f.index >= len(f.args) {
+							return f.fail(&MissingArgumentError{
+								Flag: flag, Name: flag.Name, Alias: flag.Alias, Token: v, Index: f.tokenIndex,
+							})
+						}
+						value, hasValue = f.cut(), true
+					}
+					break
 				}
+			}
 
-				return f.failf("unrecognized option `%c'", r)
+			last := len(cluster) - 1
+			for i, flag := range cluster[:last] {
+				if err := f.setValue(flag, given[i], "", false); err != nil {
+					return err
+				}
 			}
+			return f.setValue(cluster[last], given[last], value, hasValue)
 
 		} // end switch
 
 	} else {
-		flag, ok := f.flags[v]
+		flag, ok := flags[v]
 		// long name also may be one letter
 		// so, find as a short name in case of not OK
 		if !ok && len(v) == 1 {
-			flag, ok = f.flags[aliasToKey(rune(v[0]))]
+			flag, ok = flags[aliasToKey(rune(v[0]))]
+		}
+		if !ok && f.abbrevCommand && len(v) > 0 {
+			var candidates []string
+			if flag, candidates = f.lookupPrefix(v, true); flag != nil {
+				ok = true
+			} else if len(candidates) > 0 {
+				f.cut()
+				return f.fail(&UnknownCommandError{
+					Name: v, Token: v, Index: f.tokenIndex, Candidates: candidates,
+				})
+			}
 		}
 
 		if ok && flag.IsSubCommand() {
 			f.cut()
-			f.addSubCommandName(flag.Name)
-			f.flags = flag.flags
+			if flag == f.helpCommand {
+				return f.help()
+			}
+			f.path = append(f.path, flag)
+			f.warnDeprecated(flag, v)
+			if flag.posix {
+				f.stopAtArg = true
+			}
 			return flag.Value.Set("true")
 		}
 
+		// in posix mode the first non-flag argument ends the options
+		if f.stopAtArg {
+			f.index = len(f.args)
+			return nil
+		}
+
 		f.index++
 	}
 
 	return nil
 }
 
+// handleError behaves as described by the ErrorHandling.
+func (f *FlagSet) handleError(err error) error {
+	switch f.errorHandling {
+	case ExitOnError:
+		os.Exit(2)
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
 // Parse parses flag definitions from the argument list
 func (f *FlagSet) Parse(arguments []string) error {
-	f.parsed = true
-	f.args = arguments
-	f.index = 0
+	f.startParse()
+
+	if f.responseFiles {
+		args, err := expandResponseFiles(arguments)
+		if err != nil {
+			return f.handleError(f.fail(err))
+		}
+		arguments = args
+	}
+	// arguments are cut out of args, so copy them not to modify the caller's
+	f.args = append([]string(nil), arguments...)
 
+	var errs []error
 	for f.index < len(f.args) {
 		err := f.parseOne()
+		if err == nil {
+			continue
+		}
+		if !f.collectErrors || err == ErrHelp {
+			return f.handleError(err)
+		}
+		errs = append(errs, err)
+	}
 
-		if err != nil {
-			switch f.errorHandling {
-			case ContinueOnError:
-				return err
-			case ExitOnError:
-				os.Exit(2)
-			case PanicOnError:
-				panic(err)
-			}
+	if len(errs) == 0 {
+		if err := f.bindArguments(); err != nil {
+			errs = append(errs, f.fail(err))
 		}
 	}
 
-	return nil
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return f.handleError(errs[0])
+	}
+	return f.handleError(&MultiError{Errors: errs})
 }
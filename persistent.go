@@ -0,0 +1,136 @@
+package flago
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetPersistent sets whether the flag is persistent. A persistent flag
+// remains valid in all of the descendant subcommands of the scope it is
+// defined in, and must not be redefined in them, while a flag that is not
+// persistent may be shadowed by a sub-flag of the same name. Redefinition is detected
+// when the flag is added to the flag set or to a sub-command; for a flag
+// already added, use FlagSet.SetPersistent.
+func (f *Flag) SetPersistent(persistent bool) *Flag {
+	f.persistent = persistent
+	return f
+}
+
+// IsPersistent reports whether the flag is persistent.
+func (f *Flag) IsPersistent() bool {
+	return f.persistent
+}
+
+// SetPersistent makes the named flag of the flag set persistent, so that it
+// remains valid after a subcommand is selected. It panics if the flag is not
+// defined, or if it is redefined in any of the subcommands.
+func (f *FlagSet) SetPersistent(name string) {
+	flag, ok := f.flags[name]
+	if !ok || flag.IsSubCommand() {
+		s := fmt.Sprintf("flag not defined: %s", name)
+		fmt.Fprintln(f.Output(), s)
+		panic(s)
+	}
+	flag.persistent = true
+	root := f.root()
+	root.checkPersistent(root.flags, nil)
+}
+
+// SetPersistent makes the named top-level command-line flag persistent.
+func SetPersistent(name string) {
+	CommandLine.SetPersistent(name)
+}
+
+// checkPersistent panics if a persistent flag of the parent scopes, given as
+// inherited, or of flags is redefined in flags or in its subcommands.
+func (f *FlagSet) checkPersistent(flags map[string]*Flag, inherited map[string]*Flag) {
+	next := make(map[string]*Flag, len(inherited))
+	for k, v := range inherited {
+		next[k] = v
+	}
+
+	for k, v := range flags {
+		if v.IsSubCommand() {
+			continue
+		}
+		if p, ok := inherited[k]; ok && p != v {
+			name := k
+			if strings.HasPrefix(k, _ALIAS_PREFIX) {
+				name = "-" + strings.TrimPrefix(k, _ALIAS_PREFIX)
+			}
+			s := fmt.Sprintf("persistent flag redefined: %s", name)
+			fmt.Fprintln(f.Output(), s)
+			panic(s)
+		}
+		if v.persistent {
+			next[k] = v
+		}
+	}
+
+	if len(next) == 0 {
+		return
+	}
+	for k, v := range flags {
+		if k == v.Name && v.IsSubCommand() {
+			f.checkPersistent(v.flags, next)
+		}
+	}
+}
+
+// parentFlags returns the flags of the i'th subcommand selected,
+// or of the top level if i is negative.
+func (f *FlagSet) parentFlags(i int) map[string]*Flag {
+	if i < 0 {
+		return f.flags
+	}
+	return f.path[i].flags
+}
+
+// lookupFlag returns the flag, not a subcommand, with the key in the deepest
+// subcommand selected, or the persistent flag of the parent scopes.
+func (f *FlagSet) lookupFlag(key string) (*Flag, bool) {
+	if flag, ok := f.scopeFlags()[key]; ok && !flag.IsSubCommand() {
+		return flag, true
+	}
+	// the nearest parent first
+	for i := len(f.path) - 2; i >= -1; i-- {
+		if flag, ok := f.parentFlags(i)[key]; ok && flag.persistent && !flag.IsSubCommand() {
+			return flag, true
+		}
+	}
+	return nil, false
+}
+
+// inheritedFlags returns the persistent flags of the parent scopes that are
+// valid in the deepest subcommand selected.
+func (f *FlagSet) inheritedFlags() map[string]*Flag {
+	scope := f.scopeFlags()
+	flags := make(map[string]*Flag)
+	for i := len(f.path) - 2; i >= -1; i-- {
+		for k, v := range f.parentFlags(i) {
+			if !v.persistent || v.IsSubCommand() {
+				continue
+			}
+			if _, ok := flags[k]; ok {
+				continue
+			}
+			if w, ok := scope[k]; ok && !w.IsSubCommand() {
+				continue
+			}
+			flags[k] = v
+		}
+	}
+	return flags
+}
+
+// optionFlags returns the flags, not subcommands, valid in the deepest
+// subcommand selected, including the inherited ones.
+func (f *FlagSet) optionFlags() map[string]*Flag {
+	flags := f.inheritedFlags()
+	for k, v := range f.scopeFlags() {
+		if !v.IsSubCommand() {
+			flags[k] = v
+		}
+	}
+	return flags
+}
@@ -0,0 +1,91 @@
+package flago
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// maxResponseDepth is the limit of response files including other response files.
+const maxResponseDepth = 10
+
+// SetResponseFiles sets whether an argument of the form "@file" is replaced
+// by the arguments read from file before parsing. The file is split into
+// arguments by SplitArgs without variable expansion, so arguments may be
+// quoted and "#" starts a comment. A response file may refer to
+// other response files. An argument beginning with "@@" is passed through
+// with the first "@" removed, and arguments after "--" are never expanded.
+func (f *FlagSet) SetResponseFiles(expand bool) {
+	f.responseFiles = expand
+}
+
+// SetResponseFiles sets whether "@file" command-line arguments are replaced
+// by the arguments read from file.
+func SetResponseFiles(expand bool) {
+	CommandLine.SetResponseFiles(expand)
+}
+
+type responseExpander struct {
+	stack  []string // response files being read, to detect the cycle
+	dashed bool     // "--" was found, the rest are not expanded
+}
+
+// expandResponseFiles returns the arguments with each "@file" replaced by the
+// arguments read from file.
+func expandResponseFiles(args []string) ([]string, error) {
+	e := &responseExpander{}
+	return e.expand(args, nil)
+}
+
+func (e *responseExpander) expand(args []string, result []string) ([]string, error) {
+	var err error
+	for _, arg := range args {
+		switch {
+		case e.dashed:
+			result = append(result, arg)
+		case arg == "--":
+			e.dashed = true
+			result = append(result, arg)
+		case strings.HasPrefix(arg, "@@"):
+			result = append(result, arg[1:])
+		case len(arg) > 1 && arg[0] == '@':
+			if result, err = e.read(arg[1:], result); err != nil {
+				return nil, err
+			}
+		default:
+			result = append(result, arg)
+		}
+	}
+	return result, nil
+}
+
+func (e *responseExpander) read(path string, result []string) ([]string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range e.stack {
+		if v == abs {
+			return nil, fmt.Errorf("%s: response file includes itself", path)
+		}
+	}
+	if len(e.stack) >= maxResponseDepth {
+		return nil, fmt.Errorf("%s: response files nested too deeply", path)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := SplitArgs(string(b), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", path, err)
+	}
+
+	e.stack = append(e.stack, abs)
+	result, err = e.expand(args, result)
+	e.stack = e.stack[:len(e.stack)-1]
+	return result, err
+}
@@ -0,0 +1,241 @@
+package flago
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A SyntaxError is returned by SplitArgs for malformed input.
+// Line and Column are 1-based, and Column counts runes.
+type SyntaxError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// SplitArgs splits s into arguments like a POSIX shell does.
+//
+// Arguments are separated by white space, including newlines.
+// Characters in single quotes are taken literally.
+// In double quotes, a backslash escapes only $, `, ", \ and newline.
+// Elsewhere a backslash escapes any character, and a backslash-newline is
+// removed. A "#" at the beginning of an argument starts a comment that
+// continues to the end of the line.
+//
+// If lookup is not nil, $NAME and ${NAME} outside single quotes are replaced
+// by the value returned by lookup, or by the empty string if it reports the
+// variable is not set. Unlike a shell, the result of an expansion is not
+// split further. If lookup is nil, "$" is an ordinary character.
+func SplitArgs(s string, lookup func(string) (string, bool)) ([]string, error) {
+	sp := &splitter{src: []rune(s), line: 1, col: 1, lookup: lookup}
+	return sp.split()
+}
+
+// ParseString splits s into arguments by SplitArgs and parses them.
+func (f *FlagSet) ParseString(s string, lookup func(string) (string, bool)) error {
+	args, err := SplitArgs(s, lookup)
+	if err != nil {
+		f.startParse()
+		return f.handleError(f.fail(err))
+	}
+	return f.Parse(args)
+}
+
+type splitter struct {
+	src       []rune
+	pos       int
+	line, col int // position of src[pos]
+	lookup    func(string) (string, bool)
+}
+
+func (s *splitter) errorf(line, col int, format string, a ...interface{}) error {
+	return &SyntaxError{Line: line, Column: col, Msg: fmt.Sprintf(format, a...)}
+}
+
+func (s *splitter) peek() (rune, bool) {
+	if s.pos >= len(s.src) {
+		return 0, false
+	}
+	return s.src[s.pos], true
+}
+
+func (s *splitter) next() (rune, bool) {
+	r, ok := s.peek()
+	if !ok {
+		return 0, false
+	}
+	s.pos++
+	if r == '\n' {
+		s.line++
+		s.col = 1
+	} else {
+		s.col++
+	}
+	return r, true
+}
+
+func (s *splitter) split() ([]string, error) {
+	var args []string
+	var b strings.Builder
+	inArg := false
+
+	for {
+		line, col := s.line, s.col
+		r, ok := s.next()
+		if !ok {
+			break
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, b.String())
+				b.Reset()
+				inArg = false
+			}
+
+		case r == '#' && !inArg:
+			for r, ok := s.peek(); ok && r != '\n'; r, ok = s.peek() {
+				s.next()
+			}
+
+		case r == '\\':
+			e, ok := s.next()
+			if !ok {
+				return nil, s.errorf(line, col, "backslash at end of input")
+			}
+			if e != '\n' {
+				b.WriteRune(e)
+				inArg = true
+			}
+
+		case r == '\'':
+			inArg = true
+			for {
+				r, ok := s.next()
+				if !ok {
+					return nil, s.errorf(line, col, "unterminated single-quoted string")
+				}
+				if r == '\'' {
+					break
+				}
+				b.WriteRune(r)
+			}
+
+		case r == '"':
+			inArg = true
+			if err := s.doubleQuoted(&b, line, col); err != nil {
+				return nil, err
+			}
+
+		case r == '$' && s.lookup != nil:
+			// an unquoted expansion to the empty string is not an argument
+			n := b.Len()
+			if err := s.expand(&b, line, col); err != nil {
+				return nil, err
+			}
+			inArg = inArg || b.Len() > n
+
+		default:
+			b.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, b.String())
+	}
+	return args, nil
+}
+
+// doubleQuoted reads the rest of a double-quoted string, the opening quote
+// of which is at line and col.
+func (s *splitter) doubleQuoted(b *strings.Builder, line, col int) error {
+	for {
+		l, c := s.line, s.col
+		r, ok := s.next()
+		if !ok {
+			return s.errorf(line, col, "unterminated double-quoted string")
+		}
+
+		switch r {
+		case '"':
+			return nil
+		case '\\':
+			e, ok := s.peek()
+			if !ok {
+				continue
+			}
+			switch e {
+			case '$', '`', '"', '\\':
+				s.next()
+				b.WriteRune(e)
+			case '\n':
+				s.next()
+			default:
+				b.WriteRune(r)
+			}
+		case '$':
+			if s.lookup == nil {
+				b.WriteRune(r)
+				continue
+			}
+			if err := s.expand(b, l, c); err != nil {
+				return err
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func isNameRune(r rune, first bool) bool {
+	return r == '_' || unicode.IsLetter(r) || !first && unicode.IsDigit(r)
+}
+
+// expand writes the value of the variable following "$", which is at line and col.
+// A "$" not followed by a variable name is written as it is.
+func (s *splitter) expand(b *strings.Builder, line, col int) error {
+	r, ok := s.peek()
+	switch {
+	case ok && r == '{':
+		s.next()
+		var name []rune
+		for {
+			r, ok := s.next()
+			if !ok {
+				return s.errorf(line, col, "unterminated ${")
+			}
+			if r == '}' {
+				break
+			}
+			if !isNameRune(r, len(name) == 0) {
+				return s.errorf(line, col, "bad substitution")
+			}
+			name = append(name, r)
+		}
+		if len(name) == 0 {
+			return s.errorf(line, col, "bad substitution")
+		}
+		v, _ := s.lookup(string(name))
+		b.WriteString(v)
+
+	case ok && isNameRune(r, true):
+		var name []rune
+		for r, ok := s.peek(); ok && isNameRune(r, false); r, ok = s.peek() {
+			s.next()
+			name = append(name, r)
+		}
+		v, _ := s.lookup(string(name))
+		b.WriteString(v)
+
+	default:
+		b.WriteRune('$')
+	}
+	return nil
+}
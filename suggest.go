@@ -0,0 +1,156 @@
+package flago
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultSuggestionDistance is the edit distance within which a name is suggested.
+const defaultSuggestionDistance = 2
+
+// SetSuggestions sets whether an unknown option or subcommand is reported
+// with suggestions of similar names. The default is true.
+func (f *FlagSet) SetSuggestions(enabled bool) {
+	f.noSuggestions = !enabled
+}
+
+// SetSuggestions sets whether an unknown command-line option or subcommand
+// is reported with suggestions of similar names.
+func SetSuggestions(enabled bool) {
+	CommandLine.SetSuggestions(enabled)
+}
+
+// SetSuggestionDistance sets the maximum edit distance between an unknown
+// name and a name to be suggested. If d is 0 or less, the default 2 is used.
+func (f *FlagSet) SetSuggestionDistance(d int) {
+	f.suggestionDistance = d
+}
+
+// SetSuggestionDistance sets the maximum edit distance between an unknown
+// command-line name and a name to be suggested.
+func SetSuggestionDistance(d int) {
+	CommandLine.SetSuggestionDistance(d)
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([]int, len(t)+1)
+	for j := range d {
+		d[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		prev := d[0]
+		d[0] = i
+		for j := 1; j <= len(t); j++ {
+			cur := d[j]
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[j] = prev + cost
+			if d[j-1]+1 < d[j] {
+				d[j] = d[j-1] + 1
+			}
+			if cur+1 < d[j] {
+				d[j] = cur + 1
+			}
+			prev = cur
+		}
+	}
+	return d[len(t)]
+}
+
+type suggestion struct {
+	name     string
+	distance int
+}
+
+// suggest returns the candidates similar to name, the closest first.
+// A candidate is similar if it is within the edit distance, or if it begins
+// with name. Each candidate is a pair of the name to compare and the name to
+// suggest.
+func (f *FlagSet) suggest(name string, candidates [][2]string) []string {
+	if f.noSuggestions || name == "" {
+		return nil
+	}
+	max := f.suggestionDistance
+	if max <= 0 {
+		max = defaultSuggestionDistance
+	}
+
+	// a short name is similar to anything within the distance
+	if n := utf8.RuneCountInString(name) - 1; n < max {
+		max = n
+	}
+
+	var found []suggestion
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if seen[c[1]] {
+			continue
+		}
+		d := levenshtein(name, c[0])
+		if d <= max || strings.HasPrefix(c[0], name) {
+			found = append(found, suggestion{name: c[1], distance: d})
+			seen[c[1]] = true
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].distance != found[j].distance {
+			return found[i].distance < found[j].distance
+		}
+		return found[i].name < found[j].name
+	})
+
+	result := make([]string, len(found))
+	for i, v := range found {
+		result[i] = v.name
+	}
+	return result
+}
+
+// suggestFlags returns the options similar to name in the current scope.
+// If short is true, name was given after a single "-".
+func (f *FlagSet) suggestFlags(name string, short bool) []string {
+	var candidates [][2]string
+	for k, v := range f.optionFlags() {
+		if v.hidden {
+			continue
+		}
+		if k != v.Name {
+			// short names are only similar to a single letter given as a long option
+			if !short && utf8.RuneCountInString(name) == 1 {
+				a := string(v.Alias)
+				candidates = append(candidates, [2]string{a, "-" + a})
+			}
+			continue
+		}
+		candidates = append(candidates, [2]string{k, "--" + k})
+		if isNegatable(v) {
+			candidates = append(candidates, [2]string{"no-" + k, "--no-" + k})
+		}
+	}
+	return f.suggest(name, candidates)
+}
+
+// suggestCommands returns the subcommands similar to name in the current scope.
+func (f *FlagSet) suggestCommands(name string) []string {
+	var candidates [][2]string
+	for k, v := range f.scopeFlags() {
+		// long aliases of the commands are also candidates
+		if v.IsSubCommand() && !v.hidden && !strings.HasPrefix(k, _ALIAS_PREFIX) {
+			candidates = append(candidates, [2]string{k, k})
+		}
+	}
+	return f.suggest(name, candidates)
+}
+
+// didYouMean returns the message suggesting the names.
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return "; did you mean `" + strings.Join(suggestions, "' or `") + "'?"
+}
@@ -0,0 +1,258 @@
+//go:build go1.18
+
+package flago
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// A TypedValue is a Value of the type T, which is parsed and formatted by
+// the functions it is made with.
+type TypedValue[T any] struct {
+	p      *T
+	def    T
+	parse  func(string) (T, error)
+	format func(T) string
+}
+
+// NewTypedValue returns a Value storing in p, set to value. If format is
+// nil, the value is formatted with fmt.Sprint. It panics if parse is nil.
+func NewTypedValue[T any](p *T, value T, parse func(string) (T, error), format func(T) string) *TypedValue[T] {
+	if parse == nil {
+		panic("typed value without a parse function")
+	}
+	if format == nil {
+		format = func(v T) string { return fmt.Sprint(v) }
+	}
+	*p = value
+	return &TypedValue[T]{p: p, def: value, parse: parse, format: format}
+}
+
+func (v *TypedValue[T]) reset() {
+	*v.p = v.def
+}
+
+func (v *TypedValue[T]) Set(s string) error {
+	x, err := v.parse(s)
+	if err != nil {
+		return err
+	}
+	*v.p = x
+	return nil
+}
+
+func (v *TypedValue[T]) String() string {
+	if v.p == nil {
+		return ""
+	}
+	return v.format(*v.p)
+}
+
+func (v *TypedValue[T]) Get() interface{} {
+	return *v.p
+}
+
+// Value returns the value as T.
+func (v *TypedValue[T]) Value() T {
+	return *v.p
+}
+
+func (v *TypedValue[T]) typeName() string {
+	var z T
+	return fmt.Sprintf("%T", z)
+}
+
+// Typed defines a flag of the type T on the flag set, with the specified
+// name, alias, default value, usage string, parse and format functions, and
+// callback. The return value is the address of a variable that stores the
+// value of the flag.
+func Typed[T any](f *FlagSet, name string, alias rune, value T, usage string,
+	parse func(string) (T, error), format func(T) string, callback Callback) *T {
+	p := new(T)
+	TypedVar(f, p, name, alias, value, usage, parse, format, callback)
+	return p
+}
+
+// TypedVar is like Typed, but stores the value of the flag in p.
+func TypedVar[T any](f *FlagSet, p *T, name string, alias rune, value T, usage string,
+	parse func(string) (T, error), format func(T) string, callback Callback) {
+	f.Var(newTypedValue(f, p, value, name, parse, format), name, alias, usage, 0, callback)
+}
+
+// TypedSubFlag returns a sub-flag of the type T, storing in p if p is not nil.
+func TypedSubFlag[T any](f *FlagSet, p *T, name string, alias rune, value T, usage string,
+	parse func(string) (T, error), format func(T) string, callback Callback) *Flag {
+	if p == nil {
+		p = new(T)
+	}
+	return f.Var(newTypedValue(f, p, value, name, parse, format), name, alias, usage, NESTED, callback)
+}
+
+// newTypedValue is like NewTypedValue, but prints the name of the flag to
+// the output of the flag set before it panics.
+func newTypedValue[T any](f *FlagSet, p *T, value T, name string,
+	parse func(string) (T, error), format func(T) string) *TypedValue[T] {
+	if parse == nil {
+		s := fmt.Sprintf("flag without a parse function: %s", name)
+		fmt.Fprintln(f.Output(), s)
+		panic(s)
+	}
+	return NewTypedValue(p, value, parse, format)
+}
+
+// Get returns the value of the named flag of the flag set as T. The name is
+// resolved in the subcommands selected by Parse, the deepest one first, and
+// then in the top level, which includes the persistent flags of the parent
+// scopes. Otherwise it is looked up in all the sub-commands, and must be
+// defined in only one of them. It returns an error if the flag is not found,
+// or if its value is not of the type T.
+func Get[T any](f *FlagSet, name string) (T, error) {
+	var z T
+	flag, ok := f.root().resolveFlag(name)
+	if !ok {
+		var found []*Flag
+		walkFlags(f.root().flags, func(v *Flag) {
+			if v.Name == name && !v.IsSubCommand() {
+				found = append(found, v)
+			}
+		})
+		switch len(found) {
+		case 0:
+			return z, fmt.Errorf("flag not defined: %s", name)
+		case 1:
+			flag = found[0]
+		default:
+			return z, fmt.Errorf("flag %s is defined in more than one sub-command", name)
+		}
+	}
+	v, ok := flag.Value.Get().(T)
+	if !ok {
+		return z, fmt.Errorf("flag %s is of type %T, not %T", name, flag.Value.Get(), z)
+	}
+	return v, nil
+}
+
+type signed interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+type unsigned interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+func parseSigned[T signed](bits int) func(string) (T, error) {
+	return func(s string) (T, error) {
+		v, err := strconv.ParseInt(s, 0, bits)
+		if err != nil {
+			return 0, numError(err)
+		}
+		return T(v), nil
+	}
+}
+
+func formatSigned[T signed](v T) string {
+	return strconv.FormatInt(int64(v), 10)
+}
+
+func parseUnsigned[T unsigned](bits int) func(string) (T, error) {
+	return func(s string) (T, error) {
+		v, err := strconv.ParseUint(s, 0, bits)
+		if err != nil {
+			return 0, numError(err)
+		}
+		return T(v), nil
+	}
+}
+
+func formatUnsigned[T unsigned](v T) string {
+	return strconv.FormatUint(uint64(v), 10)
+}
+
+func parseFloat32(s string) (float32, error) {
+	v, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return float32(v), nil
+}
+
+func formatFloat32(v float32) string {
+	return strconv.FormatFloat(float64(v), 'g', -1, 32)
+}
+
+func parseComplex128(s string) (complex128, error) {
+	v, err := strconv.ParseComplex(s, 128)
+	if err != nil {
+		return 0, numError(err)
+	}
+	return v, nil
+}
+
+func formatComplex128(v complex128) string {
+	return strconv.FormatComplex(v, 'g', -1, 128)
+}
+
+func (f *FlagSet) Int8(name string, alias rune, value int8, usage string, callback Callback) *int8 {
+	return Typed(f, name, alias, value, usage, parseSigned[int8](8), formatSigned[int8], callback)
+}
+
+func (f *FlagSet) Int16(name string, alias rune, value int16, usage string, callback Callback) *int16 {
+	return Typed(f, name, alias, value, usage, parseSigned[int16](16), formatSigned[int16], callback)
+}
+
+func (f *FlagSet) Int32(name string, alias rune, value int32, usage string, callback Callback) *int32 {
+	return Typed(f, name, alias, value, usage, parseSigned[int32](32), formatSigned[int32], callback)
+}
+
+func (f *FlagSet) Uint8(name string, alias rune, value uint8, usage string, callback Callback) *uint8 {
+	return Typed(f, name, alias, value, usage, parseUnsigned[uint8](8), formatUnsigned[uint8], callback)
+}
+
+func (f *FlagSet) Uint16(name string, alias rune, value uint16, usage string, callback Callback) *uint16 {
+	return Typed(f, name, alias, value, usage, parseUnsigned[uint16](16), formatUnsigned[uint16], callback)
+}
+
+func (f *FlagSet) Uint32(name string, alias rune, value uint32, usage string, callback Callback) *uint32 {
+	return Typed(f, name, alias, value, usage, parseUnsigned[uint32](32), formatUnsigned[uint32], callback)
+}
+
+func (f *FlagSet) Float32(name string, alias rune, value float32, usage string, callback Callback) *float32 {
+	return Typed(f, name, alias, value, usage, parseFloat32, formatFloat32, callback)
+}
+
+func (f *FlagSet) Complex128(name string, alias rune, value complex128, usage string, callback Callback) *complex128 {
+	return Typed(f, name, alias, value, usage, parseComplex128, formatComplex128, callback)
+}
+
+func Int8(name string, alias rune, value int8, usage string, callback Callback) *int8 {
+	return CommandLine.Int8(name, alias, value, usage, callback)
+}
+
+func Int16(name string, alias rune, value int16, usage string, callback Callback) *int16 {
+	return CommandLine.Int16(name, alias, value, usage, callback)
+}
+
+func Int32(name string, alias rune, value int32, usage string, callback Callback) *int32 {
+	return CommandLine.Int32(name, alias, value, usage, callback)
+}
+
+func Uint8(name string, alias rune, value uint8, usage string, callback Callback) *uint8 {
+	return CommandLine.Uint8(name, alias, value, usage, callback)
+}
+
+func Uint16(name string, alias rune, value uint16, usage string, callback Callback) *uint16 {
+	return CommandLine.Uint16(name, alias, value, usage, callback)
+}
+
+func Uint32(name string, alias rune, value uint32, usage string, callback Callback) *uint32 {
+	return CommandLine.Uint32(name, alias, value, usage, callback)
+}
+
+func Float32(name string, alias rune, value float32, usage string, callback Callback) *float32 {
+	return CommandLine.Float32(name, alias, value, usage, callback)
+}
+
+func Complex128(name string, alias rune, value complex128, usage string, callback Callback) *complex128 {
+	return CommandLine.Complex128(name, alias, value, usage, callback)
+}
@@ -0,0 +1,112 @@
+//go:build go1.18
+
+package flago
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestTyped(t *testing.T) {
+	fs := NewFlagSet("typed test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	i8 := fs.Int8("int8", -1, 0, "", nil)
+	i16 := fs.Int16("int16", -1, 0, "", nil)
+	i32 := fs.Int32("int32", -1, 0, "", nil)
+	u8 := fs.Uint8("uint8", -1, 0, "", nil)
+	u16 := fs.Uint16("uint16", -1, 0, "", nil)
+	u32 := fs.Uint32("uint32", -1, 0, "", nil)
+	f32 := fs.Float32("float32", -1, 0, "", nil)
+	c128 := fs.Complex128("complex128", -1, 0, "", nil)
+	upper := Typed(fs, "upper", 'u', "A", "", func(s string) (string, error) {
+		return strings.ToUpper(s), nil
+	}, nil, nil)
+	fs.String("string", -1, "", "", nil)
+
+	if err := fs.Parse([]string{
+		"--int8", "-8", "--int16", "16", "--int32", "0x20", "--uint8", "8", "--uint16", "16",
+		"--uint32", "32", "--float32", "1.5", "--complex128", "1+2i", "-u", "abc", "--string", "s",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprintf("%v %v %v %v %v %v %v %v %s", *i8, *i16, *i32, *u8, *u16, *u32, *f32, *c128, *upper)
+	if want := "-8 16 32 8 16 32 1.5 (1+2i) ABC"; got != want {
+		t.Errorf("values - got: %s, want: %s", got, want)
+	}
+
+	if v, err := Get[int8](fs, "int8"); err != nil || v != -8 {
+		t.Errorf("Get - got: %v, %v, want: -8", v, err)
+	}
+	if v, err := Get[string](fs, "string"); err != nil || v != "s" {
+		t.Errorf("Get - got: %v, %v, want: s", v, err)
+	}
+	if _, err := Get[int](fs, "int8"); err == nil {
+		t.Error("Get of a different type did not fail")
+	}
+	if _, err := Get[int](fs, "undefined"); err == nil {
+		t.Error("Get of an undefined flag did not fail")
+	}
+
+	data := []struct {
+		args []string
+		err  error
+	}{
+		{args: []string{"--int8", "128"}, err: ErrRange},
+		{args: []string{"--uint16", "-1"}, err: ErrParse},
+		{args: []string{"--complex128", "x"}, err: ErrParse},
+	}
+	for i, v := range data {
+		if err := fs.Parse(v.args); !errors.Is(err, v.err) {
+			t.Errorf(" %d: error - got: %v, want: %v", i, err, v.err)
+		}
+	}
+
+	flag := fs.Lookup("float32")
+	if name, _ := UnquoteUsage(flag); name != "float32" || ValueType(flag) != "float32" {
+		t.Errorf("type - got: %s, %s, want: float32", name, ValueType(flag))
+	}
+}
+
+func TestTypedGetScope(t *testing.T) {
+	fs := NewFlagSet("typed scope test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Int8("level", -1, 1, "", nil)
+	fs.SetPersistent("level")
+	serve := fs.AddCommand("serve", -1, "", nil)
+	serve.Flags().Int16("port", 'p', 80, "", nil)
+	fs.AddCommand("run", -1, "", nil, TypedSubFlag(fs, nil, "port", -1, uint8(1), "", parseUnsigned[uint8](8), nil, nil))
+	fs.AddCommand("log", -1, "", nil, TypedSubFlag(fs, nil, "depth", -1, uint8(1), "", parseUnsigned[uint8](8), nil, nil))
+
+	if err := fs.Parse([]string{"serve", "-p", "8080", "--level", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := Get[int16](fs, "port"); err != nil || v != 8080 {
+		t.Errorf("Get sub-flag - got: %v, %v, want: 8080", v, err)
+	}
+	if v, err := Get[int8](fs, "level"); err != nil || v != 3 {
+		t.Errorf("Get persistent flag - got: %v, %v, want: 3", v, err)
+	}
+	// not in the selected scope, but defined in only one sub-command
+	if v, err := Get[uint8](fs, "depth"); err != nil || v != 1 {
+		t.Errorf("Get unselected sub-flag - got: %v, %v, want: 1", v, err)
+	}
+
+	fs.Reset()
+	if _, err := Get[int16](fs, "port"); err == nil {
+		t.Error("Get of a name defined in more than one sub-command did not fail")
+	}
+}
+
+func TestTypedNilParse(t *testing.T) {
+	fs := NewFlagSet("typed nil test", ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("nil parse function did not panic")
+		}
+	}()
+	Typed[int](fs, "x", -1, 0, "", nil, nil, nil)
+}
@@ -13,8 +13,8 @@ var (
 )
 
 func (f *FlagSet) defaultUsage() {
-	fmt.Fprintf(f.Output(), "\nUsage: %s\n\n", f.name)
-	f.PrintDefaults()
+	fmt.Fprintf(f.Output(), "\nUsage: %s\n\n", f.synopsis())
+	f.PrintCommandDefaults()
 }
 
 func (f *FlagSet) usage() {
@@ -30,8 +30,9 @@ func sortFlags(flags map[string]*Flag) []*Flag {
 	list := make(sort.StringSlice, 0, len(flags))
 	i := 0
 	for k, v := range flags {
-		// Excluding a mapping by the short name of the flag, because of duplication
-		if strings.HasPrefix(k, _ALIAS_PREFIX) {
+		// Excluding a mapping by the short name of the flag or an alias of
+		// the sub-command, because of duplication
+		if k != v.Name {
 			continue
 		}
 		list = append(list, v.Name)
@@ -59,6 +60,31 @@ func VisitAll(fn func(int, *Flag)) {
 	CommandLine.VisitAll(fn)
 }
 
+// Visit visits the flags that have been set in lexicographical order,
+// calling fn for each with the subcommand depth it was set in,
+// 1 for the top level.
+func (f *FlagSet) Visit(fn func(int, *Flag)) {
+	list := make([]*Flag, 0, len(f.actual))
+	for flag := range f.actual {
+		list = append(list, flag)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return f.actual[list[i]].depth < f.actual[list[j]].depth
+	})
+	for _, flag := range list {
+		fn(f.actual[flag].depth, flag)
+	}
+}
+
+// Visit visits the command-line flags that have been set in lexicographical
+// order, calling fn for each.
+func Visit(fn func(int, *Flag)) {
+	CommandLine.Visit(fn)
+}
+
 // Lookup returns the Flag structure of the named flag, returning nil if none exists.
 func (f *FlagSet) Lookup(name string) *Flag {
 	return f.flags[name]
@@ -75,7 +101,16 @@ func Lookup(name string) *Flag {
 // Given "a `name` to show" it returns ("name", "a name to show").
 // If there are no back quotes, the name is an educated guess of the
 // type of the flag's value, or the empty string if the flag is boolean.
+// If the value of the flag is optional, the name is enclosed as "[=name]".
 func UnquoteUsage(flag *Flag) (name string, usage string) {
+	name, usage = unquoteUsage(flag)
+	if flag.optional && name != "" {
+		name = "[=" + name + "]"
+	}
+	return
+}
+
+func unquoteUsage(flag *Flag) (name string, usage string) {
 	// Look for a back-quoted name, but avoid the strings package.
 	usage = flag.Usage
 	for i := 0; i < len(usage); i++ {
@@ -92,7 +127,15 @@ func UnquoteUsage(flag *Flag) (name string, usage string) {
 	}
 	// No explicit name, so use type if we can find one.
 	name = "value"
-	switch flag.Value.(type) {
+	switch v := flag.Value.(type) {
+	case *sliceValue:
+		name = v.typ + "s"
+	case *mapValue:
+		name = "key=" + v.typ
+	case *enumValue:
+		name = v.placeholder()
+	case typeNamer:
+		name = v.typeName()
 	case boolFlag:
 		name = ""
 	case *durationValue:
@@ -109,9 +152,25 @@ func UnquoteUsage(flag *Flag) (name string, usage string) {
 	return
 }
 
+// typeNamer is implemented by a Value that knows the name of its type,
+// such as TypedValue.
+type typeNamer interface {
+	typeName() string
+}
+
 // ValueType
 func ValueType(f *Flag) string {
-	switch f.Value.(type) {
+	switch v := f.Value.(type) {
+	case *sliceValue:
+		return "[]" + v.typ
+	case *mapValue:
+		return "map[string]" + v.typ
+	case *enumValue:
+		return "enum"
+	case *countValue:
+		return "count"
+	case typeNamer:
+		return v.typeName()
 	case *boolValue:
 		return "bool"
 	case *stringValue:
@@ -147,27 +206,51 @@ func IsZeroValue(flag *Flag, value string) bool {
 
 // GetFlagName
 func (f *Flag) GetFlagName() string {
+	name := f.Name
+	if isNegatable(f) {
+		name = "[no-]" + name
+	}
+	if f.optional && !f.IsSubCommand() {
+		value, _ := UnquoteUsage(f)
+		name += value
+	} else if _, ok := f.Value.(*enumValue); ok {
+		value, _ := UnquoteUsage(f)
+		name += " " + value
+	}
+	if len(f.aliases) > 0 {
+		name += ", " + strings.Join(f.aliases, ", ")
+	}
+
 	if f.Alias > 0 {
 		if f.IsSubCommand() {
-			return fmt.Sprintf(" %c,   %s  ", f.Alias, f.Name)
+			return fmt.Sprintf(" %c,   %s  ", f.Alias, name)
 		}
-		return fmt.Sprintf("-%c, --%s", f.Alias, f.Name)
+		return fmt.Sprintf("-%c, --%s", f.Alias, name)
 	}
 
 	if f.IsSubCommand() {
-		return fmt.Sprintf("%s      ", f.Name)
+		return fmt.Sprintf("%s      ", name)
 	}
-	return fmt.Sprintf("    --%s", f.Name)
+	return fmt.Sprintf("    --%s", name)
 }
 
 // PrintDefaults default value and value type is not include in the output string
 // if you need them you can define custom functions
 func (f *FlagSet) PrintDefaults() {
-	var options, command string
-	indent, pad := Indent, Pad
-	f.VisitAll(func(depth int, flag *Flag) {
-		name := flag.GetFlagName()
+	f.printDefaults(f.flags, nil, f.positional)
+}
 
+// PrintCommandDefaults is like PrintDefaults, but prints the flags and
+// subcommands of the deepest subcommand selected by Parse, and the persistent
+// flags inherited from the parent scopes as global options.
+func (f *FlagSet) PrintCommandDefaults() {
+	f.printDefaults(f.scopeFlags(), f.inheritedFlags(), f.scopeArguments())
+}
+
+func (f *FlagSet) printDefaults(flags, inherited map[string]*Flag, args []*Argument) {
+	var arguments, options, global, command string
+	indent, pad := Indent, Pad
+	format := func(depth int, name, usage string) string {
 		n := depth*indent + 2
 		if len(name) > pad {
 			n += len(name)
@@ -176,7 +259,7 @@ func (f *FlagSet) PrintDefaults() {
 		}
 
 		s := fmt.Sprintf("%s%-20s", strings.Repeat(" ", depth*indent), name)
-		for i, u := range strings.Split(flag.Usage, "\n") {
+		for i, u := range strings.Split(usage, "\n") {
 			if i > 0 {
 				s += strings.Repeat(" ", n)
 			} else {
@@ -184,18 +267,53 @@ func (f *FlagSet) PrintDefaults() {
 			}
 			s += u + "\n"
 		}
+		return s
+	}
+
+	formatFlag := func(depth int, flag *Flag) string {
+		_, usage := UnquoteUsage(flag)
+		if e, ok := flag.Value.(*enumValue); ok {
+			usage += e.choiceUsages()
+		}
+		return format(depth, flag.GetFlagName(), usage)
+	}
 
+	for _, a := range args {
+		arguments += format(1, a.synopsis(), a.Usage)
+	}
+	visitAll(1, flags, func(depth int, flag *Flag) {
+		if flag.hidden {
+			return
+		}
 		if flag.IsSubCommand() {
-			command += s
+			command += formatFlag(depth, flag)
 		} else {
-			options += s
+			options += formatFlag(depth, flag)
+		}
+	})
+	visitAll(1, inherited, func(depth int, flag *Flag) {
+		if flag.hidden {
+			return
 		}
+		global += formatFlag(depth, flag)
 	})
 
 	s := ""
+	if len(arguments) > 0 {
+		s += fmt.Sprintf("Arguments:\n%s", arguments)
+	}
 	if len(options) > 0 {
+		if len(s) > 0 {
+			s += "\n"
+		}
 		s += fmt.Sprintf("Options:\n%s", options)
 	}
+	if len(global) > 0 {
+		if len(s) > 0 {
+			s += "\n"
+		}
+		s += fmt.Sprintf("Global Options:\n%s", global)
+	}
 	if len(command) > 0 {
 		s += fmt.Sprintf("\nCommands:\n%s", command)
 	}
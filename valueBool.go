@@ -7,6 +7,7 @@ import (
 const (
 	COMMAND uint = 1 << iota
 	NESTED
+	PERSISTENT
 )
 
 type boolValue bool
@@ -91,7 +92,7 @@ func (f *FlagSet) BoolVarSubCommandNested(p *bool, name string, alias rune, usag
 func (b *boolValue) Set(s string) error {
 	v, err := strconv.ParseBool(s)
 	if err != nil {
-		err = errParse
+		err = ErrParse
 	}
 	*b = boolValue(v)
 	return err
@@ -113,3 +114,18 @@ type boolFlag interface {
 	Value
 	IsBoolFlag() bool
 }
+
+// isBoolFlag reports whether the flag does not need an argument.
+func isBoolFlag(flag *Flag) bool {
+	b, ok := flag.Value.(boolFlag)
+	return ok && b.IsBoolFlag()
+}
+
+// isNegatable reports whether "--no-name" is accepted for the flag.
+func isNegatable(flag *Flag) bool {
+	if !flag.negatable || flag.IsSubCommand() || !isBoolFlag(flag) {
+		return false
+	}
+	_, ok := flag.Value.Get().(bool)
+	return ok
+}
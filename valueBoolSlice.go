@@ -0,0 +1,48 @@
+package flago
+
+func newBoolSliceValue(p *[]bool, value []bool) *sliceValue {
+	*p = value
+	return newSliceValue(p, "bool", func() Value { return new(boolValue) })
+}
+
+func BoolSlice(name string, alias rune, value []bool, usage string, callback Callback) *[]bool {
+	p := new([]bool)
+	CommandLine.Var(newBoolSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func BoolSliceVar(p *[]bool, name string, alias rune, value []bool, usage string, callback Callback) {
+	CommandLine.Var(newBoolSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func BoolSliceSubFlag(name string, alias rune, value []bool, usage string, callback Callback) *Flag {
+	return CommandLine.BoolSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func BoolSliceVarSubFlag(p *[]bool, name string, alias rune, value []bool, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]bool)
+	}
+	return CommandLine.Var(newBoolSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
+
+func (f *FlagSet) BoolSlice(name string, alias rune, value []bool, usage string, callback Callback) *[]bool {
+	p := new([]bool)
+	f.Var(newBoolSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) BoolSliceVar(p *[]bool, name string, alias rune, value []bool, usage string, callback Callback) {
+	f.Var(newBoolSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) BoolSliceSubFlag(name string, alias rune, value []bool, usage string, callback Callback) *Flag {
+	return f.BoolSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func (f *FlagSet) BoolSliceVarSubFlag(p *[]bool, name string, alias rune, value []bool, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]bool)
+	}
+	return f.Var(newBoolSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
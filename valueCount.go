@@ -0,0 +1,56 @@
+package flago
+
+import (
+	"strconv"
+)
+
+// countValue is an int incremented each time the flag is given without a
+// value, such as "-vvv" for 3.
+type countValue int
+
+func newCountValue(p *int, value int) *countValue {
+	*p = value
+	return (*countValue)(p)
+}
+
+func Count(name string, alias rune, value int, usage string, callback Callback) *int {
+	p := new(int)
+	CommandLine.Var(newCountValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func CountVar(p *int, name string, alias rune, value int, usage string, callback Callback) {
+	CommandLine.Var(newCountValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) Count(name string, alias rune, value int, usage string, callback Callback) *int {
+	p := new(int)
+	f.Var(newCountValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) CountVar(p *int, name string, alias rune, value int, usage string, callback Callback) {
+	f.Var(newCountValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (c *countValue) Set(s string) error {
+	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	if err != nil {
+		return numError(err)
+	}
+	*c = countValue(v)
+	return nil
+}
+
+func (c *countValue) String() string {
+	return strconv.Itoa(int(*c))
+}
+
+func (c *countValue) Get() interface{} {
+	return int(*c)
+}
+
+// IsBoolFlag makes the flag take no argument, so that it is incremented instead.
+func (c *countValue) IsBoolFlag() bool {
+	return true
+}
@@ -56,7 +56,7 @@ func (f *FlagSet) DurationVarSubFlag(p *time.Duration, name string, alias rune,
 func (d *durationValue) Set(s string) error {
 	v, err := time.ParseDuration(s)
 	if err != nil {
-		err = errParse
+		err = ErrParse
 	}
 	*d = durationValue(v)
 	return err
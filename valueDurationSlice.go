@@ -0,0 +1,52 @@
+package flago
+
+import (
+	"time"
+)
+
+func newDurationSliceValue(p *[]time.Duration, value []time.Duration) *sliceValue {
+	*p = value
+	return newSliceValue(p, "duration", func() Value { return new(durationValue) })
+}
+
+func DurationSlice(name string, alias rune, value []time.Duration, usage string, callback Callback) *[]time.Duration {
+	p := new([]time.Duration)
+	CommandLine.Var(newDurationSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func DurationSliceVar(p *[]time.Duration, name string, alias rune, value []time.Duration, usage string, callback Callback) {
+	CommandLine.Var(newDurationSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func DurationSliceSubFlag(name string, alias rune, value []time.Duration, usage string, callback Callback) *Flag {
+	return CommandLine.DurationSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func DurationSliceVarSubFlag(p *[]time.Duration, name string, alias rune, value []time.Duration, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]time.Duration)
+	}
+	return CommandLine.Var(newDurationSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
+
+func (f *FlagSet) DurationSlice(name string, alias rune, value []time.Duration, usage string, callback Callback) *[]time.Duration {
+	p := new([]time.Duration)
+	f.Var(newDurationSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) DurationSliceVar(p *[]time.Duration, name string, alias rune, value []time.Duration, usage string, callback Callback) {
+	f.Var(newDurationSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) DurationSliceSubFlag(name string, alias rune, value []time.Duration, usage string, callback Callback) *Flag {
+	return f.DurationSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func (f *FlagSet) DurationSliceVarSubFlag(p *[]time.Duration, name string, alias rune, value []time.Duration, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]time.Duration)
+	}
+	return f.Var(newDurationSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
@@ -0,0 +1,133 @@
+package flago
+
+import (
+	"fmt"
+	"strings"
+)
+
+// enumValue is a string that accepts only one of the choices.
+type enumValue struct {
+	p       *string
+	def     string
+	choices []string
+	usages  map[string]string // descriptions of the choices
+	fold    bool              // choices are case-insensitive
+}
+
+// newEnumValue returns the value of the enum flag with the name. It prints
+// to the output of the flag set and panics if the default value is neither
+// empty nor one of the choices.
+func newEnumValue(f *FlagSet, p *string, name, value string, choices []string) *enumValue {
+	valid := value == ""
+	for _, c := range choices {
+		valid = valid || value == c
+	}
+	if !valid {
+		s := fmt.Sprintf("default value of flag %s is not one of the choices: %s", name, value)
+		fmt.Fprintln(f.Output(), s)
+		panic(s)
+	}
+	*p = value
+	return &enumValue{p: p, def: value, choices: append([]string(nil), choices...)}
+}
+
+func (e *enumValue) reset() {
+	*e.p = e.def
+}
+
+func Enum(name string, alias rune, value string, choices []string, usage string, callback Callback) *string {
+	p := new(string)
+	CommandLine.Var(newEnumValue(CommandLine, p, name, value, choices), name, alias, usage, 0, callback)
+	return p
+}
+
+func EnumVar(p *string, name string, alias rune, value string, choices []string, usage string, callback Callback) {
+	CommandLine.Var(newEnumValue(CommandLine, p, name, value, choices), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) Enum(name string, alias rune, value string, choices []string, usage string, callback Callback) *string {
+	p := new(string)
+	f.Var(newEnumValue(f, p, name, value, choices), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) EnumVar(p *string, name string, alias rune, value string, choices []string, usage string, callback Callback) {
+	f.Var(newEnumValue(f, p, name, value, choices), name, alias, usage, 0, callback)
+}
+
+// SetCaseInsensitive sets whether the choices of an enum flag are matched
+// case-insensitively. The value is set to the choice as it is defined.
+// It has no effect on other flags.
+func (f *Flag) SetCaseInsensitive(fold bool) *Flag {
+	if v, ok := f.Value.(*enumValue); ok {
+		v.fold = fold
+	}
+	return f
+}
+
+// SetChoiceUsage sets the description of a choice of an enum flag, which is
+// printed by PrintDefaults under the usage of the flag. It has no effect on
+// other flags.
+func (f *Flag) SetChoiceUsage(choice string, usage string) *Flag {
+	if v, ok := f.Value.(*enumValue); ok {
+		if v.usages == nil {
+			v.usages = make(map[string]string)
+		}
+		v.usages[choice] = usage
+	}
+	return f
+}
+
+// Choices returns the choices of an enum flag, such as for generating shell
+// completions, or nil for other flags.
+func (f *Flag) Choices() []string {
+	if v, ok := f.Value.(*enumValue); ok {
+		return append([]string(nil), v.choices...)
+	}
+	return nil
+}
+
+func (e *enumValue) Set(s string) error {
+	for _, c := range e.choices {
+		if s == c || e.fold && strings.EqualFold(s, c) {
+			*e.p = c
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrChoice, strings.Join(e.choices, ", "))
+}
+
+func (e *enumValue) String() string {
+	if e.p == nil {
+		return ""
+	}
+	return *e.p
+}
+
+func (e *enumValue) Get() interface{} {
+	return *e.p
+}
+
+// placeholder returns the choices as they are shown in the help message,
+// such as "{json|yaml|table}".
+func (e *enumValue) placeholder() string {
+	return "{" + strings.Join(e.choices, "|") + "}"
+}
+
+// choiceUsages returns the lines describing the choices that have one.
+func (e *enumValue) choiceUsages() string {
+	width := 0
+	for _, c := range e.choices {
+		if _, ok := e.usages[c]; ok && len(c) > width {
+			width = len(c)
+		}
+	}
+
+	s := ""
+	for _, c := range e.choices {
+		if u, ok := e.usages[c]; ok {
+			s += fmt.Sprintf("\n  %-*s  %s", width, c, u)
+		}
+	}
+	return s
+}
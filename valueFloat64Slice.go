@@ -0,0 +1,48 @@
+package flago
+
+func newFloat64SliceValue(p *[]float64, value []float64) *sliceValue {
+	*p = value
+	return newSliceValue(p, "float", func() Value { return new(float64Value) })
+}
+
+func Float64Slice(name string, alias rune, value []float64, usage string, callback Callback) *[]float64 {
+	p := new([]float64)
+	CommandLine.Var(newFloat64SliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func Float64SliceVar(p *[]float64, name string, alias rune, value []float64, usage string, callback Callback) {
+	CommandLine.Var(newFloat64SliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func Float64SliceSubFlag(name string, alias rune, value []float64, usage string, callback Callback) *Flag {
+	return CommandLine.Float64SliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func Float64SliceVarSubFlag(p *[]float64, name string, alias rune, value []float64, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]float64)
+	}
+	return CommandLine.Var(newFloat64SliceValue(p, value), name, alias, usage, NESTED, callback)
+}
+
+func (f *FlagSet) Float64Slice(name string, alias rune, value []float64, usage string, callback Callback) *[]float64 {
+	p := new([]float64)
+	f.Var(newFloat64SliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) Float64SliceVar(p *[]float64, name string, alias rune, value []float64, usage string, callback Callback) {
+	f.Var(newFloat64SliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) Float64SliceSubFlag(name string, alias rune, value []float64, usage string, callback Callback) *Flag {
+	return f.Float64SliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func (f *FlagSet) Float64SliceVarSubFlag(p *[]float64, name string, alias rune, value []float64, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]float64)
+	}
+	return f.Var(newFloat64SliceValue(p, value), name, alias, usage, NESTED, callback)
+}
@@ -0,0 +1,48 @@
+package flago
+
+func newInt64SliceValue(p *[]int64, value []int64) *sliceValue {
+	*p = value
+	return newSliceValue(p, "int", func() Value { return new(int64Value) })
+}
+
+func Int64Slice(name string, alias rune, value []int64, usage string, callback Callback) *[]int64 {
+	p := new([]int64)
+	CommandLine.Var(newInt64SliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func Int64SliceVar(p *[]int64, name string, alias rune, value []int64, usage string, callback Callback) {
+	CommandLine.Var(newInt64SliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func Int64SliceSubFlag(name string, alias rune, value []int64, usage string, callback Callback) *Flag {
+	return CommandLine.Int64SliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func Int64SliceVarSubFlag(p *[]int64, name string, alias rune, value []int64, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]int64)
+	}
+	return CommandLine.Var(newInt64SliceValue(p, value), name, alias, usage, NESTED, callback)
+}
+
+func (f *FlagSet) Int64Slice(name string, alias rune, value []int64, usage string, callback Callback) *[]int64 {
+	p := new([]int64)
+	f.Var(newInt64SliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) Int64SliceVar(p *[]int64, name string, alias rune, value []int64, usage string, callback Callback) {
+	f.Var(newInt64SliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) Int64SliceSubFlag(name string, alias rune, value []int64, usage string, callback Callback) *Flag {
+	return f.Int64SliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func (f *FlagSet) Int64SliceVarSubFlag(p *[]int64, name string, alias rune, value []int64, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]int64)
+	}
+	return f.Var(newInt64SliceValue(p, value), name, alias, usage, NESTED, callback)
+}
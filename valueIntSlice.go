@@ -0,0 +1,48 @@
+package flago
+
+func newIntSliceValue(p *[]int, value []int) *sliceValue {
+	*p = value
+	return newSliceValue(p, "int", func() Value { return new(intValue) })
+}
+
+func IntSlice(name string, alias rune, value []int, usage string, callback Callback) *[]int {
+	p := new([]int)
+	CommandLine.Var(newIntSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func IntSliceVar(p *[]int, name string, alias rune, value []int, usage string, callback Callback) {
+	CommandLine.Var(newIntSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func IntSliceSubFlag(name string, alias rune, value []int, usage string, callback Callback) *Flag {
+	return CommandLine.IntSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func IntSliceVarSubFlag(p *[]int, name string, alias rune, value []int, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]int)
+	}
+	return CommandLine.Var(newIntSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
+
+func (f *FlagSet) IntSlice(name string, alias rune, value []int, usage string, callback Callback) *[]int {
+	p := new([]int)
+	f.Var(newIntSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) IntSliceVar(p *[]int, name string, alias rune, value []int, usage string, callback Callback) {
+	f.Var(newIntSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) IntSliceSubFlag(name string, alias rune, value []int, usage string, callback Callback) *Flag {
+	return f.IntSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func (f *FlagSet) IntSliceVarSubFlag(p *[]int, name string, alias rune, value []int, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]int)
+	}
+	return f.Var(newIntSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
@@ -0,0 +1,83 @@
+package flago
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// mapValue is a map from strings to any of the built-in types. Each Set
+// adds a "key=value" pair to the map, replacing the default value on the
+// first one.
+type mapValue struct {
+	value   reflect.Value // pointer to the map
+	def     reflect.Value // the default value
+	typ     string        // name of the type of the values
+	elem    func() Value  // returns a Value to parse a value with
+	unique  bool          // a key given twice is an error
+	changed bool
+}
+
+func newMapValue(p interface{}, typ string, elem func() Value) *mapValue {
+	v := reflect.ValueOf(p)
+	return &mapValue{value: v, def: reflect.ValueOf(v.Elem().Interface()), typ: typ, elem: elem}
+}
+
+func (m *mapValue) reset() {
+	m.value.Elem().Set(m.def)
+	m.changed = false
+}
+
+// SetUniqueKeys sets whether a key given more than once to a map flag is an
+// error. By default the last value given for a key wins. It has no effect on
+// other flags.
+func (f *Flag) SetUniqueKeys(unique bool) *Flag {
+	if v, ok := f.Value.(*mapValue); ok {
+		v.unique = unique
+	}
+	return f
+}
+
+func (m *mapValue) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i <= 0 {
+		return ErrPair
+	}
+	key := s[:i]
+
+	value := m.elem()
+	if err := value.Set(s[i+1:]); err != nil {
+		return err
+	}
+
+	mp := m.value.Elem()
+	if !m.changed {
+		mp.Set(reflect.MakeMap(mp.Type()))
+		m.changed = true
+	}
+	k := reflect.ValueOf(key)
+	if m.unique && mp.MapIndex(k).IsValid() {
+		return fmt.Errorf("%w `%s'", ErrDuplicateKey, key)
+	}
+	mp.SetMapIndex(k, reflect.ValueOf(value.Get()))
+	return nil
+}
+
+// String returns the pairs sorted by key, such as "[a=1,b=2]".
+func (m *mapValue) String() string {
+	if !m.value.IsValid() {
+		return "[]"
+	}
+	mp := m.value.Elem()
+	pairs := make([]string, 0, mp.Len())
+	for _, k := range mp.MapKeys() {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k.String(), mp.MapIndex(k).Interface()))
+	}
+	sort.Strings(pairs)
+	return "[" + strings.Join(pairs, ",") + "]"
+}
+
+func (m *mapValue) Get() interface{} {
+	return m.value.Elem().Interface()
+}
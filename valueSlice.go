@@ -0,0 +1,131 @@
+package flago
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// sliceValue is a slice of any of the built-in types. Each Set appends the
+// elements to the slice, replacing the default value on the first one.
+type sliceValue struct {
+	value   reflect.Value // pointer to the slice
+	def     reflect.Value // the default value
+	typ     string        // name of the type of the elements
+	elem    func() Value  // returns a Value to parse an element with
+	sep     rune          // separator of the elements in an argument, or 0
+	changed bool
+}
+
+func newSliceValue(p interface{}, typ string, elem func() Value) *sliceValue {
+	v := reflect.ValueOf(p)
+	return &sliceValue{value: v, def: reflect.ValueOf(v.Elem().Interface()), typ: typ, elem: elem}
+}
+
+func (s *sliceValue) reset() {
+	s.value.Elem().Set(s.def)
+	s.changed = false
+}
+
+// SetSeparator sets the separator of the elements of a slice flag given in
+// one argument, such as ',' for "--tag a,b". An element containing the
+// separator can be double-quoted, as in CSV: "--tag '\"a,b\",c'". A double
+// quote elsewhere than at the beginning of an element is taken literally.
+// By default, or if sep is 0, each argument is one element. It has no effect
+// on other flags.
+func (f *Flag) SetSeparator(sep rune) *Flag {
+	if v, ok := f.Value.(*sliceValue); ok {
+		v.sep = sep
+	}
+	return f
+}
+
+func (s *sliceValue) Set(v string) error {
+	elems := []string{v}
+	if s.sep != 0 && v != "" {
+		var err error
+		if elems, err = splitElements(v, s.sep); err != nil {
+			return err
+		}
+	}
+
+	// parse all of the elements before changing the slice
+	values := make([]reflect.Value, len(elems))
+	for i, e := range elems {
+		value := s.elem()
+		if err := value.Set(e); err != nil {
+			return err
+		}
+		values[i] = reflect.ValueOf(value.Get())
+	}
+
+	slice := s.value.Elem()
+	if !s.changed {
+		slice.Set(reflect.Zero(slice.Type()))
+		s.changed = true
+	}
+	slice.Set(reflect.Append(slice, values...))
+	return nil
+}
+
+// splitElements splits v into the elements separated by sep. An element
+// beginning with a double quote is quoted up to the next double quote, and
+// "" stands for a double quote in it.
+func splitElements(v string, sep rune) ([]string, error) {
+	var elems []string
+	var b strings.Builder
+	src := []rune(v)
+	for i := 0; i <= len(src); i++ {
+		if i < len(src) && src[i] == '"' && b.Len() == 0 {
+			// quoted element, which must end at a separator
+			for i++; ; i++ {
+				if i >= len(src) {
+					return nil, ErrParse
+				}
+				if src[i] == '"' {
+					if i+1 < len(src) && src[i+1] == '"' {
+						i++
+					} else {
+						break
+					}
+				}
+				b.WriteRune(src[i])
+			}
+			if i++; i < len(src) && src[i] != sep {
+				return nil, ErrParse
+			}
+		}
+		if i == len(src) || src[i] == sep {
+			elems = append(elems, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteRune(src[i])
+	}
+	return elems, nil
+}
+
+func (s *sliceValue) String() string {
+	if !s.value.IsValid() {
+		return "[]"
+	}
+	sep := ','
+	if s.sep != 0 {
+		sep = s.sep
+	}
+
+	slice := s.value.Elem()
+	elems := make([]string, slice.Len())
+	for i := range elems {
+		e := fmt.Sprint(slice.Index(i).Interface())
+		if strings.ContainsRune(e, sep) || strings.ContainsRune(e, '"') {
+			e = `"` + strings.ReplaceAll(e, `"`, `""`) + `"`
+		}
+		elems[i] = e
+	}
+	return "[" + strings.Join(elems, string(sep)) + "]"
+}
+
+func (s *sliceValue) Get() interface{} {
+	return s.value.Elem().Interface()
+}
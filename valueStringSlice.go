@@ -0,0 +1,48 @@
+package flago
+
+func newStringSliceValue(p *[]string, value []string) *sliceValue {
+	*p = value
+	return newSliceValue(p, "string", func() Value { return new(stringValue) })
+}
+
+func StringSlice(name string, alias rune, value []string, usage string, callback Callback) *[]string {
+	p := new([]string)
+	CommandLine.Var(newStringSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func StringSliceVar(p *[]string, name string, alias rune, value []string, usage string, callback Callback) {
+	CommandLine.Var(newStringSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func StringSliceSubFlag(name string, alias rune, value []string, usage string, callback Callback) *Flag {
+	return CommandLine.StringSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func StringSliceVarSubFlag(p *[]string, name string, alias rune, value []string, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]string)
+	}
+	return CommandLine.Var(newStringSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
+
+func (f *FlagSet) StringSlice(name string, alias rune, value []string, usage string, callback Callback) *[]string {
+	p := new([]string)
+	f.Var(newStringSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) StringSliceVar(p *[]string, name string, alias rune, value []string, usage string, callback Callback) {
+	f.Var(newStringSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) StringSliceSubFlag(name string, alias rune, value []string, usage string, callback Callback) *Flag {
+	return f.StringSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func (f *FlagSet) StringSliceVarSubFlag(p *[]string, name string, alias rune, value []string, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]string)
+	}
+	return f.Var(newStringSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
@@ -0,0 +1,30 @@
+package flago
+
+import (
+	"time"
+)
+
+func newStringToDurationValue(p *map[string]time.Duration, value map[string]time.Duration) *mapValue {
+	*p = value
+	return newMapValue(p, "duration", func() Value { return new(durationValue) })
+}
+
+func StringToDuration(name string, alias rune, value map[string]time.Duration, usage string, callback Callback) *map[string]time.Duration {
+	p := new(map[string]time.Duration)
+	CommandLine.Var(newStringToDurationValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func StringToDurationVar(p *map[string]time.Duration, name string, alias rune, value map[string]time.Duration, usage string, callback Callback) {
+	CommandLine.Var(newStringToDurationValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) StringToDuration(name string, alias rune, value map[string]time.Duration, usage string, callback Callback) *map[string]time.Duration {
+	p := new(map[string]time.Duration)
+	f.Var(newStringToDurationValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) StringToDurationVar(p *map[string]time.Duration, name string, alias rune, value map[string]time.Duration, usage string, callback Callback) {
+	f.Var(newStringToDurationValue(p, value), name, alias, usage, 0, callback)
+}
@@ -0,0 +1,26 @@
+package flago
+
+func newStringToIntValue(p *map[string]int, value map[string]int) *mapValue {
+	*p = value
+	return newMapValue(p, "int", func() Value { return new(intValue) })
+}
+
+func StringToInt(name string, alias rune, value map[string]int, usage string, callback Callback) *map[string]int {
+	p := new(map[string]int)
+	CommandLine.Var(newStringToIntValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func StringToIntVar(p *map[string]int, name string, alias rune, value map[string]int, usage string, callback Callback) {
+	CommandLine.Var(newStringToIntValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) StringToInt(name string, alias rune, value map[string]int, usage string, callback Callback) *map[string]int {
+	p := new(map[string]int)
+	f.Var(newStringToIntValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) StringToIntVar(p *map[string]int, name string, alias rune, value map[string]int, usage string, callback Callback) {
+	f.Var(newStringToIntValue(p, value), name, alias, usage, 0, callback)
+}
@@ -0,0 +1,26 @@
+package flago
+
+func newStringToStringValue(p *map[string]string, value map[string]string) *mapValue {
+	*p = value
+	return newMapValue(p, "string", func() Value { return new(stringValue) })
+}
+
+func StringToString(name string, alias rune, value map[string]string, usage string, callback Callback) *map[string]string {
+	p := new(map[string]string)
+	CommandLine.Var(newStringToStringValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func StringToStringVar(p *map[string]string, name string, alias rune, value map[string]string, usage string, callback Callback) {
+	CommandLine.Var(newStringToStringValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) StringToString(name string, alias rune, value map[string]string, usage string, callback Callback) *map[string]string {
+	p := new(map[string]string)
+	f.Var(newStringToStringValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) StringToStringVar(p *map[string]string, name string, alias rune, value map[string]string, usage string, callback Callback) {
+	f.Var(newStringToStringValue(p, value), name, alias, usage, 0, callback)
+}
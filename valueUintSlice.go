@@ -0,0 +1,48 @@
+package flago
+
+func newUintSliceValue(p *[]uint, value []uint) *sliceValue {
+	*p = value
+	return newSliceValue(p, "uint", func() Value { return new(uintValue) })
+}
+
+func UintSlice(name string, alias rune, value []uint, usage string, callback Callback) *[]uint {
+	p := new([]uint)
+	CommandLine.Var(newUintSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func UintSliceVar(p *[]uint, name string, alias rune, value []uint, usage string, callback Callback) {
+	CommandLine.Var(newUintSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func UintSliceSubFlag(name string, alias rune, value []uint, usage string, callback Callback) *Flag {
+	return CommandLine.UintSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func UintSliceVarSubFlag(p *[]uint, name string, alias rune, value []uint, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]uint)
+	}
+	return CommandLine.Var(newUintSliceValue(p, value), name, alias, usage, NESTED, callback)
+}
+
+func (f *FlagSet) UintSlice(name string, alias rune, value []uint, usage string, callback Callback) *[]uint {
+	p := new([]uint)
+	f.Var(newUintSliceValue(p, value), name, alias, usage, 0, callback)
+	return p
+}
+
+func (f *FlagSet) UintSliceVar(p *[]uint, name string, alias rune, value []uint, usage string, callback Callback) {
+	f.Var(newUintSliceValue(p, value), name, alias, usage, 0, callback)
+}
+
+func (f *FlagSet) UintSliceSubFlag(name string, alias rune, value []uint, usage string, callback Callback) *Flag {
+	return f.UintSliceVarSubFlag(nil, name, alias, value, usage, callback)
+}
+
+func (f *FlagSet) UintSliceVarSubFlag(p *[]uint, name string, alias rune, value []uint, usage string, callback Callback) *Flag {
+	if p == nil {
+		p = new([]uint)
+	}
+	return f.Var(newUintSliceValue(p, value), name, alias, usage, NESTED, callback)
+}